@@ -0,0 +1,9 @@
+// Package docs embeds the documentation files letme uses at runtime
+package docs
+
+import _ "embed"
+
+// Example catalog item, used to seed new tables
+//
+//go:embed dynamodb_structure.json
+var DynamoDbStructure []byte
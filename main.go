@@ -4,6 +4,8 @@ import (
 	"fmt"
 	utils "github.com/lockedinspace/letme/pkg"
 	letme "github.com/lockedinspace/letme/pkg/cmd"
+	_ "github.com/lockedinspace/letme/pkg/cmd/admin"
+	_ "github.com/lockedinspace/letme/pkg/cmd/catalog"
 	_ "github.com/lockedinspace/letme/pkg/cmd/config"
 )
 
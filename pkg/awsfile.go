@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// Content of the aws files as a dry run left them, later reads see the changes which were not written
+var dryRunAwsFiles = make(map[string][]byte)
+
+// Block of lines of an aws credentials/config file holding a section along with the comments right above its header
+type awsFileBlock struct {
+	name     string
+	lines    []string
+	trailing []string
+}
+
+// Save an aws credentials/config file editing only the sections managed by letme. Sections letme does not manage,
+// comments, blank lines and anything before the first section are kept byte for byte. Managed sections which did not
+// change are kept as they are, the rest are rewritten in place, removed, or appended at the end of the file. The
+// previous content of the file is backed up before it changes, a dry run only prints the changes.
+func SaveAwsFile(file *ini.File, path string) error {
+	original, err := readAwsFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	exists := err == nil
+	originalFile := ini.Empty()
+	if len(original) > 0 {
+		if originalFile, err = ini.Load(original); err != nil {
+			return err
+		}
+	}
+
+	preamble, blocks := splitAwsFile(string(original))
+	var content strings.Builder
+	content.WriteString(strings.Join(preamble, ""))
+
+	written := make(map[string]bool)
+	for _, block := range blocks {
+		section, err := file.GetSection(block.name)
+		originalSection, _ := originalFile.GetSection(block.name)
+		switch {
+		// sections removed from the file, or repeated headers which ini merged into the first one
+		case err != nil || written[block.name]:
+			continue
+		case originalSection != nil && !IsLetmeManaged(originalSection) && !IsLetmeManaged(section):
+			content.WriteString(strings.Join(block.lines, ""))
+		case originalSection != nil && renderAwsSection(originalSection) == renderAwsSection(section):
+			content.WriteString(strings.Join(block.lines, ""))
+		default:
+			content.WriteString(renderAwsSection(section))
+		}
+		content.WriteString(strings.Join(block.trailing, ""))
+		written[block.name] = true
+	}
+
+	for _, section := range file.Sections() {
+		// keys without section are part of the lines before the first section
+		if written[section.Name()] || section.Name() == ini.DefaultSection {
+			continue
+		}
+		if content.Len() > 0 {
+			text := content.String()
+			if !strings.HasSuffix(text, "\n") {
+				content.WriteString("\n")
+			}
+			if !strings.HasSuffix(text, "\n\n") {
+				content.WriteString("\n")
+			}
+		}
+		content.WriteString(renderAwsSection(section))
+		written[section.Name()] = true
+	}
+
+	if content.String() == string(original) {
+		return nil
+	}
+	if DryRun {
+		PrintUnifiedDiff(path, string(original), content.String())
+		dryRunAwsFiles[path] = []byte(content.String())
+		return nil
+	}
+	if err := BackupAwsFile(path, original, exists); err != nil {
+		return err
+	}
+	return writeFileAtomically(path, []byte(content.String()))
+}
+
+// Read an aws credentials/config file, including the changes made during a dry run
+func readAwsFile(path string) ([]byte, error) {
+	if content, ok := dryRunAwsFiles[path]; ok {
+		return content, nil
+	}
+	return os.ReadFile(path)
+}
+
+// Split the content of an aws credentials/config file into the lines before the first section and one block per
+// section. Comment lines right above a section header belong to that section, blank lines at the end of a section
+// are kept apart so they survive when the section is rewritten.
+func splitAwsFile(content string) ([]string, []awsFileBlock) {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var headers []int
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.Contains(trimmed, "]") {
+			headers = append(headers, i)
+		}
+	}
+
+	// move the start of every block up to the comments right above its header
+	starts := make([]int, len(headers))
+	for i, header := range headers {
+		start := header
+		limit := 0
+		if i > 0 {
+			limit = headers[i-1] + 1
+		}
+		for start > limit && isAwsFileComment(lines[start-1]) {
+			start--
+		}
+		starts[i] = start
+	}
+
+	if len(headers) == 0 {
+		return lines, nil
+	}
+	blocks := make([]awsFileBlock, len(headers))
+	for i, header := range headers {
+		end := len(lines)
+		if i < len(headers)-1 {
+			end = starts[i+1]
+		}
+		body := lines[starts[i]:end]
+		split := len(body)
+		for split > headers[i]-starts[i]+1 && len(strings.TrimSpace(body[split-1])) == 0 {
+			split--
+		}
+		trimmed := strings.TrimSpace(lines[header])
+		blocks[i] = awsFileBlock{
+			name:     strings.TrimSpace(trimmed[1:strings.LastIndex(trimmed, "]")]),
+			lines:    body[:split],
+			trailing: body[split:],
+		}
+	}
+	return lines[:starts[0]], blocks
+}
+
+// Check if a line of an aws credentials/config file is a comment
+func isAwsFileComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#")
+}
+
+// Render a section the way the aws cli writes it, values are never quoted
+func renderAwsSection(section *ini.Section) string {
+	var text strings.Builder
+	if len(section.Comment) > 0 {
+		for _, line := range strings.Split(section.Comment, "\n") {
+			if !isAwsFileComment(line) {
+				line = "; " + line
+			}
+			text.WriteString(line + "\n")
+		}
+	}
+	if section.Name() != ini.DefaultSection {
+		text.WriteString("[" + section.Name() + "]\n")
+	}
+	for _, key := range section.Keys() {
+		text.WriteString(key.Name() + " = " + key.Value() + "\n")
+	}
+	return text.String()
+}
+
+// Replace a file through a temporary file on the same directory, so readers never see it half written. The
+// permissions of the previous file are kept, new files are only readable by the user.
+func writeFileAtomically(path string, content []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	temporaryFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporaryFile.Name())
+	if _, err := temporaryFile.Write(content); err != nil {
+		temporaryFile.Close()
+		return err
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temporaryFile.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(temporaryFile.Name(), path)
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the expected files of the golden tests")
+
+// Comment letme writes above its sections, with a fixed creation time so the output can be compared
+const testManagedComment = "; letme managed context=test created=2026-01-01T00:00:00Z"
+
+// Add a section the way letme writes it
+func setTestManagedSection(file *ini.File, name string, keys ...string) {
+	section := file.Section(name)
+	for _, key := range section.KeyStrings() {
+		section.DeleteKey(key)
+	}
+	section.Comment = testManagedComment
+	for i := 0; i < len(keys); i += 2 {
+		section.Key(keys[i]).SetValue(keys[i+1])
+	}
+}
+
+// Every case loads testdata/awsfile/<case>/input, edits it like a letme command would and compares the saved file
+// byte for byte with testdata/awsfile/<case>/expected. Cases without an input file start from a missing file.
+func TestSaveAwsFileGolden(t *testing.T) {
+	cases := map[string]func(file *ini.File){
+		"config_managed_rewrite": func(file *ini.File) {
+			setTestManagedSection(file, "profile acct", "region", "us-east-1", "output", "json")
+		},
+		"config_unmanaged_untouched": func(file *ini.File) {
+			// edits to sections letme does not manage are never written
+			file.Section("profile mine").Key("region").SetValue("eu-central-1")
+			setTestManagedSection(file, "profile acct", "region", "eu-west-1", "output", "json")
+		},
+		"credentials_comments_blank_lines": func(file *ini.File) {
+			file.DeleteSection("old")
+			setTestManagedSection(file, "new", "aws_access_key_id", "ASIANEW", "aws_secret_access_key", "new", "aws_session_token", "new")
+		},
+		"credentials_quoted_values": func(file *ini.File) {
+			setTestManagedSection(file, "acct", "aws_access_key_id", "ASIANEW", "aws_secret_access_key", "new/secret+=", "aws_session_token", "new")
+		},
+		"credentials_comment_above_managed": func(file *ini.File) {
+			// the comment written by hand above the marker is kept, the creation time too
+			UseContext("test")
+			defer UseContext("")
+			section := file.Section("acct")
+			ClaimLetmeSection(section)
+			section.Key("aws_access_key_id").SetValue("ASIANEW")
+			section.Key("aws_secret_access_key").SetValue("new")
+			section.Key("aws_session_token").SetValue("new")
+		},
+		"config_empty_file": func(file *ini.File) {
+			setTestManagedSection(file, "profile acct", "region", "eu-west-1", "output", "json")
+		},
+		"config_missing_file": func(file *ini.File) {
+			setTestManagedSection(file, "profile acct", "region", "eu-west-1", "output", "json")
+		},
+	}
+
+	for name, edit := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			path := filepath.Join(t.TempDir(), "file")
+			file := ini.Empty()
+			input, err := os.ReadFile(filepath.Join("testdata", "awsfile", name, "input"))
+			switch {
+			case err == nil:
+				if err := os.WriteFile(path, input, 0600); err != nil {
+					t.Fatal(err)
+				}
+				if file, err = ini.Load(input); err != nil {
+					t.Fatal(err)
+				}
+			case !os.IsNotExist(err):
+				t.Fatal(err)
+			}
+
+			edit(file)
+			if err := SaveAwsFile(file, path); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedPath := filepath.Join("testdata", "awsfile", name, "expected")
+			if *updateGolden {
+				if err := os.WriteFile(expectedPath, saved, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(saved) != string(expected) {
+				t.Errorf("saved file does not match %s:\n%s", expectedPath, UnifiedDiff(string(expected), string(saved)))
+			}
+		})
+	}
+}
+
+// Saving a file without changes must not write nor back it up
+func TestSaveAwsFileUnchanged(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "file")
+	input, err := os.ReadFile(filepath.Join("testdata", "awsfile", "config_managed_rewrite", "input"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, input, 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := ini.Load(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveAwsFile(file, path); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != string(input) {
+		t.Errorf("unchanged file was rewritten:\n%s", UnifiedDiff(string(input), string(saved)))
+	}
+	if backups := ListAwsFileBackups(); len(backups) > 0 {
+		t.Errorf("unchanged file was backed up: %v", backups)
+	}
+}
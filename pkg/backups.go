@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Number of backups of the aws files kept on the backups directory, older ones are removed
+const MaxAwsFileBackups = 20
+
+// Layout of the backup identifiers, which sort in the order the backups were taken
+const awsFileBackupIdLayout = "20060102T150405.000000000Z"
+
+// Aws files which can be backed up and restored
+var awsBackupFiles = []string{"credentials", "config"}
+
+// Suffix of the empty file a backup holds in place of an aws file which did not exist, restoring it deletes the file
+const absentBackupSuffix = ".absent"
+
+// Backup taken by the current letme command, every aws file is only saved once per command
+var awsFileBackupId string
+
+// Backup of the aws files as they were before a letme command changed them
+type AwsFileBackup struct {
+	Id      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Command string    `json:"command"`
+	Files   []string  `json:"files"`
+	Absent  []string  `json:"absent,omitempty"`
+}
+
+// Return the directory holding the backups of the aws files
+func AwsFileBackupsDirectory() string {
+	return GetHomeDirectory() + "/.letme/backups"
+}
+
+// Save the previous content of an aws file before letme changes it, files which did not exist are recorded as absent.
+// The first backup of a command also records the command which changed the files and rotates the oldest backups out.
+func BackupAwsFile(path string, content []byte, exists bool) error {
+	fileName := filepath.Base(path)
+	if !slices.Contains(awsBackupFiles, fileName) {
+		return nil
+	}
+
+	if len(awsFileBackupId) == 0 {
+		awsFileBackupId = time.Now().UTC().Format(awsFileBackupIdLayout)
+		if err := os.MkdirAll(AwsFileBackupsDirectory()+"/"+awsFileBackupId, 0700); err != nil {
+			return err
+		}
+		command := "letme " + strings.Join(os.Args[1:], " ")
+		if err := os.WriteFile(AwsFileBackupsDirectory()+"/"+awsFileBackupId+"/command", []byte(command), 0600); err != nil {
+			return err
+		}
+		if err := rotateAwsFileBackups(); err != nil {
+			return err
+		}
+	}
+
+	backupPath := AwsFileBackupsDirectory() + "/" + awsFileBackupId + "/" + fileName
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(backupPath + absentBackupSuffix); err == nil {
+		return nil
+	}
+	if !exists {
+		return os.WriteFile(backupPath+absentBackupSuffix, nil, 0600)
+	}
+	return os.WriteFile(backupPath, content, 0600)
+}
+
+// Remove the oldest backups so only the most recent ones are kept
+func rotateAwsFileBackups() error {
+	backups := ListAwsFileBackups()
+	for len(backups) > MaxAwsFileBackups {
+		if err := DeleteAwsFileBackup(backups[len(backups)-1].Id); err != nil {
+			return err
+		}
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// List the backups of the aws files, most recent first
+func ListAwsFileBackups() []AwsFileBackup {
+	entries, err := os.ReadDir(AwsFileBackupsDirectory())
+	if err != nil {
+		return nil
+	}
+
+	var backups []AwsFileBackup
+	for _, entry := range entries {
+		created, err := time.Parse(awsFileBackupIdLayout, entry.Name())
+		if !entry.IsDir() || err != nil {
+			continue
+		}
+		backup := AwsFileBackup{Id: entry.Name(), Created: created}
+		if command, err := os.ReadFile(AwsFileBackupsDirectory() + "/" + entry.Name() + "/command"); err == nil {
+			backup.Command = string(command)
+		}
+		for _, fileName := range awsBackupFiles {
+			if _, err := os.Stat(AwsFileBackupsDirectory() + "/" + entry.Name() + "/" + fileName); err == nil {
+				backup.Files = append(backup.Files, fileName)
+			} else if _, err := os.Stat(AwsFileBackupsDirectory() + "/" + entry.Name() + "/" + fileName + absentBackupSuffix); err == nil {
+				backup.Files = append(backup.Files, fileName)
+				backup.Absent = append(backup.Absent, fileName)
+			}
+		}
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Id > backups[j].Id
+	})
+	return backups
+}
+
+// Return a backup of the aws files by its identifier
+func GetAwsFileBackup(id string) (AwsFileBackup, error) {
+	for _, backup := range ListAwsFileBackups() {
+		if backup.Id == id {
+			return backup, nil
+		}
+	}
+	return AwsFileBackup{}, fmt.Errorf("letme: backup '%s' not found, run 'letme backups list' to list available backups", id)
+}
+
+// Restore the aws files saved on a backup, files which did not exist when the backup was taken are deleted. When
+// keepCurrent is set the files being replaced are backed up first, so the restore can be reverted as well.
+func RestoreAwsFileBackup(backup AwsFileBackup, keepCurrent bool) error {
+	contents := make(map[string][]byte)
+	for _, fileName := range backup.Files {
+		if slices.Contains(backup.Absent, fileName) {
+			continue
+		}
+		content, err := os.ReadFile(AwsFileBackupsDirectory() + "/" + backup.Id + "/" + fileName)
+		if err != nil {
+			return err
+		}
+		contents[fileName] = content
+	}
+
+	for _, fileName := range backup.Files {
+		path := GetHomeDirectory() + "/.aws/" + fileName
+		current, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if keepCurrent {
+			if err := BackupAwsFile(path, current, err == nil); err != nil {
+				return err
+			}
+		}
+		if slices.Contains(backup.Absent, fileName) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := writeFileAtomically(path, contents[fileName]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete a backup of the aws files
+func DeleteAwsFileBackup(id string) error {
+	return os.RemoveAll(AwsFileBackupsDirectory() + "/" + id)
+}
+
+// Delete every backup of the aws files, including the one taken by the current command, returning how many were
+// deleted. Backups hold whole credentials files, so they are cleared when logging out.
+func ClearAwsFileBackups() (int, error) {
+	backups := ListAwsFileBackups()
+	for _, backup := range backups {
+		if err := DeleteAwsFileBackup(backup.Id); err != nil {
+			return 0, err
+		}
+	}
+	awsFileBackupId = ""
+	return len(backups), nil
+}
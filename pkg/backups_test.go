@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gopkg.in/ini.v1"
+)
+
+// Start a new letme command on an empty home directory, returning the path of its aws config file
+func setupBackupTest(t *testing.T) string {
+	t.Setenv("HOME", t.TempDir())
+	awsFileBackupId = ""
+	t.Cleanup(func() { awsFileBackupId = "" })
+	if err := os.MkdirAll(filepath.Join(os.Getenv("HOME"), ".aws"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(os.Getenv("HOME"), ".aws", "config")
+}
+
+// Save a managed profile on the aws config file and return the backup taken before
+func saveBackedUpAwsFile(t *testing.T, path string) AwsFileBackup {
+	file := ini.Empty()
+	setTestManagedSection(file, "profile acct", "region", "eu-west-1")
+	if err := SaveAwsFile(file, path); err != nil {
+		t.Fatal(err)
+	}
+	backups := ListAwsFileBackups()
+	if len(backups) != 1 || !slices.Equal(backups[0].Files, []string{"config"}) {
+		t.Fatalf("expected a backup of the config file, found %v", backups)
+	}
+	// the restore runs as another command
+	awsFileBackupId = ""
+	return backups[0]
+}
+
+// Restoring the backup of a file which did not exist deletes it, the restore can be reverted
+func TestBackupMissingAwsFile(t *testing.T) {
+	path := setupBackupTest(t)
+	backup := saveBackedUpAwsFile(t, path)
+	if !slices.Equal(backup.Absent, []string{"config"}) {
+		t.Errorf("config file is not recorded as absent: %v", backup)
+	}
+
+	if err := RestoreAwsFileBackup(backup, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("restored config file exists: %v", err)
+	}
+	if backups := ListAwsFileBackups(); len(backups) != 2 || len(backups[0].Absent) > 0 {
+		t.Errorf("restore did not back up the current config file: %v", backups)
+	}
+}
+
+// Empty files are backed up and restored empty
+func TestBackupEmptyAwsFile(t *testing.T) {
+	path := setupBackupTest(t)
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	backup := saveBackedUpAwsFile(t, path)
+	if len(backup.Absent) > 0 {
+		t.Errorf("empty config file is recorded as absent: %v", backup)
+	}
+
+	if err := RestoreAwsFileBackup(backup, false); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(path); err != nil || len(content) > 0 {
+		t.Errorf("restored config file is not empty: %q %v", content, err)
+	}
+}
+
+func TestClearAwsFileBackups(t *testing.T) {
+	path := setupBackupTest(t)
+	saveBackedUpAwsFile(t, path)
+	cleared, err := ClearAwsFileBackups()
+	if err != nil || cleared != 1 {
+		t.Errorf("cleared %v backups: %v", cleared, err)
+	}
+	if backups := ListAwsFileBackups(); len(backups) > 0 {
+		t.Errorf("backups left after clearing them: %v", backups)
+	}
+}
@@ -0,0 +1,676 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Role arns follow the arn:$PARTITION:iam::$ACCOUNT:role/$PATH/$ROLE_NAME format
+var roleArnPattern = regexp.MustCompile(`^arn:aws(-cn|-us-gov|-iso|-iso-b)?:iam::\d{12}:role/[\w+=,.@/-]{1,512}$`)
+
+// Managed policy arns follow the arn:$PARTITION:iam::$ACCOUNT:policy/$PATH/$POLICY_NAME format, aws managed ones use 'aws' as account
+var policyArnPattern = regexp.MustCompile(`^arn:aws(-cn|-us-gov|-iso|-iso-b)?:iam::(\d{12}|aws):policy/[\w+=,.@/-]{1,512}$`)
+
+// Region names such as eu-west-1, us-gov-west-1 or cn-north-1
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-gov|-iso|-isob)?-[a-z]+-\d{1,2}$`)
+
+// Tags are single words, they are used in filters and profile names
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:/+=@-]*$`)
+
+// Check if a role arn is well formed
+func ValidRoleArn(arn string) bool {
+	return roleArnPattern.MatchString(arn)
+}
+
+// Check if a region name is well formed. Regions are checked by their format rather than against a list, so regions
+// launched after a letme release are accepted.
+func ValidRegion(region string) bool {
+	return regionPattern.MatchString(region)
+}
+
+// Check if a tag is well formed
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// Check if a catalog item can be obtained by letme, returning every problem found
+func ValidateAccountConfig(account *DynamoDbAccountConfig) []error {
+	var problems []error
+	if len(strings.TrimSpace(account.Name)) == 0 || strings.ContainsAny(account.Name, " \t\n") {
+		problems = append(problems, fmt.Errorf("letme: account name '%s' must be a non empty word", account.Name))
+	}
+
+	if len(account.Region) == 0 {
+		problems = append(problems, fmt.Errorf("letme: account '%s' must have at least one region", account.Name))
+	}
+	for _, region := range account.Region {
+		if !ValidRegion(region) {
+			problems = append(problems, fmt.Errorf("letme: account '%s' has an invalid region '%s'", account.Name, region))
+		}
+	}
+
+	if len(account.Role) == 0 && len(account.Roles) == 0 {
+		problems = append(problems, fmt.Errorf("letme: account '%s' must have a role chain or named roles", account.Name))
+	}
+	problems = append(problems, validateRoleChain(account.Name, "role", account.Role)...)
+	for _, roleName := range GetAccountRoleNames(account) {
+		if len(account.Roles[roleName]) == 0 {
+			problems = append(problems, fmt.Errorf("letme: account '%s' has an empty role chain for role '%s'", account.Name, roleName))
+		}
+		problems = append(problems, validateRoleChain(account.Name, "role '"+roleName+"'", account.Roles[roleName])...)
+	}
+	if _, ok := account.Roles[account.DefaultRole]; len(account.DefaultRole) > 0 && !ok {
+		problems = append(problems, fmt.Errorf("letme: account '%s' default role '%s' is not one of its named roles", account.Name, account.DefaultRole))
+	}
+
+	for _, tag := range account.Tags {
+		if !ValidTag(tag) {
+			problems = append(problems, fmt.Errorf("letme: account '%s' has an invalid tag '%s', tags are single words without spaces or commas", account.Name, tag))
+		}
+	}
+	for key := range account.SessionTags {
+		if len(key) == 0 {
+			problems = append(problems, fmt.Errorf("letme: account '%s' has a session tag without key", account.Name))
+		}
+	}
+	for _, policyArn := range account.SessionPolicyArns {
+		if !policyArnPattern.MatchString(policyArn) {
+			problems = append(problems, fmt.Errorf("letme: account '%s' has an invalid session policy arn '%s'", account.Name, policyArn))
+		}
+	}
+	if len(account.SessionPolicy) > 0 && !json.Valid([]byte(account.SessionPolicy)) {
+		problems = append(problems, fmt.Errorf("letme: account '%s' session policy is not valid json", account.Name))
+	}
+	return problems
+}
+
+// Check every hop of a role chain
+func validateRoleChain(accountName string, chainName string, chain []RoleHop) []error {
+	var problems []error
+	for i, hop := range chain {
+		if !ValidRoleArn(hop.Arn) {
+			problems = append(problems, fmt.Errorf("letme: account '%s' %s hop %v has an invalid role arn '%s'", accountName, chainName, i+1, hop.Arn))
+		}
+		if len(hop.Region) > 0 && !ValidRegion(hop.Region) {
+			problems = append(problems, fmt.Errorf("letme: account '%s' %s hop %v has an invalid region '%s'", accountName, chainName, i+1, hop.Region))
+		}
+		if hop.SessionDuration != 0 && (hop.SessionDuration < 900 || hop.SessionDuration > 43200) {
+			problems = append(problems, fmt.Errorf("letme: account '%s' %s hop %v duration must be between 900 and 43200 seconds", accountName, chainName, i+1))
+		}
+	}
+	return problems
+}
+
+// Read catalog item attributes from a json or yaml file, the format is picked from the file extension
+func ReadAccountItemFile(path string) (map[string]dynamodbTypes.AttributeValue, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes map[string]interface{}
+	switch CatalogFormatFromPath(path) {
+	case "yaml":
+		err = yaml.Unmarshal(content, &attributes)
+	default:
+		err = json.Unmarshal(content, &attributes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("letme: unable to parse '%s': %v", path, err)
+	}
+	return attributevalue.MarshalMap(attributes)
+}
+
+// Unmarshal catalog item attributes into an account
+func AccountFromItem(item map[string]dynamodbTypes.AttributeValue) (*DynamoDbAccountConfig, error) {
+	account := new(DynamoDbAccountConfig)
+	if err := attributevalue.UnmarshalMap(item, account); err != nil {
+		return nil, fmt.Errorf("letme: invalid catalog item: %v", err)
+	}
+	return account, nil
+}
+
+// Return every attribute of a catalog item, including the ones letme does not use. Returns nil if the item does not exist.
+func GetAccountItem(awsDynamoDbTable string, cfg aws.Config, accountName string) (map[string]dynamodbTypes.AttributeValue, error) {
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	resp, err := sesAwsDynamoDb.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName:      aws.String(awsDynamoDbTable),
+		Key:            map[string]dynamodbTypes.AttributeValue{"name": &dynamodbTypes.AttributeValueMemberS{Value: accountName}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Item, nil
+}
+
+// Create a catalog item, failing if an item with the same name already exists
+func PutNewAccountItem(awsDynamoDbTable string, cfg aws.Config, item map[string]dynamodbTypes.AttributeValue) error {
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	_, err := sesAwsDynamoDb.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName:                aws.String(awsDynamoDbTable),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#name)"),
+		ExpressionAttributeNames: map[string]string{"#name": "name"},
+	})
+	if isConditionalCheckFailed(err) {
+		var accountName string
+		attributevalue.Unmarshal(item["name"], &accountName)
+		return fmt.Errorf("letme: account '%s' already exists on table '%s', use 'letme admin account update' to change it", accountName, awsDynamoDbTable)
+	}
+	return err
+}
+
+// Change the given attributes of a catalog item, a nil value removes the attribute. The write only succeeds if the
+// changed attributes still hold the values read in previous, so edits made by someone else in the meantime are kept.
+func UpdateAccountItem(awsDynamoDbTable string, cfg aws.Config, previous map[string]dynamodbTypes.AttributeValue, changes map[string]dynamodbTypes.AttributeValue) error {
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	names := map[string]string{"#name": "name"}
+	values := make(map[string]dynamodbTypes.AttributeValue)
+	conditions := []string{"attribute_exists(#name)"}
+	var set, remove []string
+	for i, key := range keys {
+		name := fmt.Sprintf("#a%v", i)
+		names[name] = key
+		if changes[key] == nil {
+			remove = append(remove, name)
+		} else {
+			values[fmt.Sprintf(":new%v", i)] = changes[key]
+			set = append(set, fmt.Sprintf("%s = :new%v", name, i))
+		}
+		if old, ok := previous[key]; ok {
+			values[fmt.Sprintf(":old%v", i)] = old
+			conditions = append(conditions, fmt.Sprintf("%s = :old%v", name, i))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("attribute_not_exists(%s)", name))
+		}
+	}
+
+	var updateExpression []string
+	if len(set) > 0 {
+		updateExpression = append(updateExpression, "SET "+strings.Join(set, ", "))
+	}
+	if len(remove) > 0 {
+		updateExpression = append(updateExpression, "REMOVE "+strings.Join(remove, ", "))
+	}
+	if len(updateExpression) == 0 {
+		return nil
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                aws.String(awsDynamoDbTable),
+		Key:                      map[string]dynamodbTypes.AttributeValue{"name": previous["name"]},
+		UpdateExpression:         aws.String(strings.Join(updateExpression, " ")),
+		ConditionExpression:      aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames: names,
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	_, err := sesAwsDynamoDb.UpdateItem(context.TODO(), input)
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("letme: account was changed by someone else since it was read, run the command again")
+	}
+	return err
+}
+
+// Delete a catalog item, failing if it does not exist
+func DeleteAccountItem(awsDynamoDbTable string, cfg aws.Config, accountName string) error {
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	_, err := sesAwsDynamoDb.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName:                aws.String(awsDynamoDbTable),
+		Key:                      map[string]dynamodbTypes.AttributeValue{"name": &dynamodbTypes.AttributeValueMemberS{Value: accountName}},
+		ConditionExpression:      aws.String("attribute_exists(#name)"),
+		ExpressionAttributeNames: map[string]string{"#name": "name"},
+	})
+	if isConditionalCheckFailed(err) {
+		return fmt.Errorf("letme: account '%s' does not exist on table '%s'", accountName, awsDynamoDbTable)
+	}
+	return err
+}
+
+// Check if dynamodb rejected a write because its condition did not hold
+func isConditionalCheckFailed(err error) bool {
+	var conditionalCheckFailed *dynamodbTypes.ConditionalCheckFailedException
+	return errors.As(err, &conditionalCheckFailed)
+}
+
+// Create a catalog table keyed by account name, billed on demand. With tagIndex its tag index table is created too,
+// see CreateTagIndexTable. Point in time recovery is optional and applies to both tables.
+func CreateCatalogTable(awsDynamoDbTable string, cfg aws.Config, tagIndex bool, pointInTimeRecovery bool) error {
+	err := createTable(cfg, &dynamodb.CreateTableInput{
+		TableName:   aws.String(awsDynamoDbTable),
+		BillingMode: dynamodbTypes.BillingModePayPerRequest,
+		AttributeDefinitions: []dynamodbTypes.AttributeDefinition{
+			{AttributeName: aws.String("name"), AttributeType: dynamodbTypes.ScalarAttributeTypeS},
+		},
+		KeySchema: []dynamodbTypes.KeySchemaElement{
+			{AttributeName: aws.String("name"), KeyType: dynamodbTypes.KeyTypeHash},
+		},
+	}, pointInTimeRecovery)
+	if err != nil || !tagIndex {
+		return err
+	}
+	return CreateTagIndexTable(awsDynamoDbTable, cfg, pointInTimeRecovery)
+}
+
+// Create the tag index table of a catalog table, keyed by tag and account name, billed on demand
+func CreateTagIndexTable(awsDynamoDbTable string, cfg aws.Config, pointInTimeRecovery bool) error {
+	return createTable(cfg, &dynamodb.CreateTableInput{
+		TableName:   aws.String(TagIndexTable(awsDynamoDbTable)),
+		BillingMode: dynamodbTypes.BillingModePayPerRequest,
+		AttributeDefinitions: []dynamodbTypes.AttributeDefinition{
+			{AttributeName: aws.String(TagIndexTagAttribute), AttributeType: dynamodbTypes.ScalarAttributeTypeS},
+			{AttributeName: aws.String(TagIndexAccountAttribute), AttributeType: dynamodbTypes.ScalarAttributeTypeS},
+		},
+		KeySchema: []dynamodbTypes.KeySchemaElement{
+			{AttributeName: aws.String(TagIndexTagAttribute), KeyType: dynamodbTypes.KeyTypeHash},
+			{AttributeName: aws.String(TagIndexAccountAttribute), KeyType: dynamodbTypes.KeyTypeRange},
+		},
+	}, pointInTimeRecovery)
+}
+
+// Create a table and wait until it exists, then enable point in time recovery if asked to
+func createTable(cfg aws.Config, input *dynamodb.CreateTableInput, pointInTimeRecovery bool) error {
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	if _, err := sesAwsDynamoDb.CreateTable(context.TODO(), input); err != nil {
+		return err
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(sesAwsDynamoDb)
+	if err := waiter.Wait(context.TODO(), &dynamodb.DescribeTableInput{TableName: input.TableName}, 5*time.Minute); err != nil {
+		return err
+	}
+
+	if pointInTimeRecovery {
+		_, err := sesAwsDynamoDb.UpdateContinuousBackups(context.TODO(), &dynamodb.UpdateContinuousBackupsInput{
+			TableName:                        input.TableName,
+			PointInTimeRecoverySpecification: &dynamodbTypes.PointInTimeRecoverySpecification{PointInTimeRecoveryEnabled: aws.Bool(true)},
+		})
+		return err
+	}
+	return nil
+}
+
+// Return a cloudformation template creating the same tables as CreateCatalogTable
+func CatalogTableCloudFormation(awsDynamoDbTable string, tagIndex bool, pointInTimeRecovery bool) string {
+	var template strings.Builder
+	template.WriteString("AWSTemplateFormatVersion: \"2010-09-09\"\n")
+	template.WriteString("Description: letme account catalog\n")
+	template.WriteString("Resources:\n")
+	template.WriteString("  LetmeCatalogTable:\n")
+	template.WriteString("    Type: AWS::DynamoDB::Table\n")
+	template.WriteString("    Properties:\n")
+	fmt.Fprintf(&template, "      TableName: %s\n", awsDynamoDbTable)
+	template.WriteString("      BillingMode: PAY_PER_REQUEST\n")
+	template.WriteString("      AttributeDefinitions:\n")
+	template.WriteString("        - AttributeName: name\n")
+	template.WriteString("          AttributeType: S\n")
+	template.WriteString("      KeySchema:\n")
+	template.WriteString("        - AttributeName: name\n")
+	template.WriteString("          KeyType: HASH\n")
+	if pointInTimeRecovery {
+		template.WriteString("      PointInTimeRecoverySpecification:\n")
+		template.WriteString("        PointInTimeRecoveryEnabled: true\n")
+	}
+	if tagIndex {
+		template.WriteString("  LetmeTagIndexTable:\n")
+		template.WriteString("    Type: AWS::DynamoDB::Table\n")
+		template.WriteString("    Properties:\n")
+		fmt.Fprintf(&template, "      TableName: %s\n", TagIndexTable(awsDynamoDbTable))
+		template.WriteString("      BillingMode: PAY_PER_REQUEST\n")
+		template.WriteString("      AttributeDefinitions:\n")
+		fmt.Fprintf(&template, "        - AttributeName: %s\n", TagIndexTagAttribute)
+		template.WriteString("          AttributeType: S\n")
+		fmt.Fprintf(&template, "        - AttributeName: %s\n", TagIndexAccountAttribute)
+		template.WriteString("          AttributeType: S\n")
+		template.WriteString("      KeySchema:\n")
+		fmt.Fprintf(&template, "        - AttributeName: %s\n", TagIndexTagAttribute)
+		template.WriteString("          KeyType: HASH\n")
+		fmt.Fprintf(&template, "        - AttributeName: %s\n", TagIndexAccountAttribute)
+		template.WriteString("          KeyType: RANGE\n")
+		if pointInTimeRecovery {
+			template.WriteString("      PointInTimeRecoverySpecification:\n")
+			template.WriteString("        PointInTimeRecoveryEnabled: true\n")
+		}
+	}
+	template.WriteString("Outputs:\n")
+	template.WriteString("  TableName:\n")
+	template.WriteString("    Value: !Ref LetmeCatalogTable\n")
+	if tagIndex {
+		template.WriteString("  TagIndexTableName:\n")
+		template.WriteString("    Value: !Ref LetmeTagIndexTable\n")
+	}
+	return template.String()
+}
+
+// Return a terraform configuration creating the same tables as CreateCatalogTable
+func CatalogTableTerraform(awsDynamoDbTable string, tagIndex bool, pointInTimeRecovery bool) string {
+	var configuration strings.Builder
+	configuration.WriteString("resource \"aws_dynamodb_table\" \"letme_catalog\" {\n")
+	fmt.Fprintf(&configuration, "  name         = %q\n", awsDynamoDbTable)
+	configuration.WriteString("  billing_mode = \"PAY_PER_REQUEST\"\n")
+	configuration.WriteString("  hash_key     = \"name\"\n\n")
+	configuration.WriteString("  attribute {\n    name = \"name\"\n    type = \"S\"\n  }\n")
+	if pointInTimeRecovery {
+		configuration.WriteString("\n  point_in_time_recovery {\n    enabled = true\n  }\n")
+	}
+	configuration.WriteString("}\n")
+	if tagIndex {
+		configuration.WriteString("\nresource \"aws_dynamodb_table\" \"letme_tag_index\" {\n")
+		fmt.Fprintf(&configuration, "  name         = %q\n", TagIndexTable(awsDynamoDbTable))
+		configuration.WriteString("  billing_mode = \"PAY_PER_REQUEST\"\n")
+		fmt.Fprintf(&configuration, "  hash_key     = %q\n", TagIndexTagAttribute)
+		fmt.Fprintf(&configuration, "  range_key    = %q\n\n", TagIndexAccountAttribute)
+		fmt.Fprintf(&configuration, "  attribute {\n    name = %q\n    type = \"S\"\n  }\n", TagIndexTagAttribute)
+		fmt.Fprintf(&configuration, "\n  attribute {\n    name = %q\n    type = \"S\"\n  }\n", TagIndexAccountAttribute)
+		if pointInTimeRecovery {
+			configuration.WriteString("\n  point_in_time_recovery {\n    enabled = true\n  }\n")
+		}
+		configuration.WriteString("}\n")
+	}
+	return configuration.String()
+}
+
+// Return the catalog table of the current context, or the given one, and the aws config of the context source profile
+func GetCatalogConfig(awsDynamoDbTable string) (string, aws.Config) {
+	awsDynamoDbTable, cfg, err := LoadCatalogConfig(awsDynamoDbTable)
+	CheckAndReturnError(err)
+	return awsDynamoDbTable, cfg
+}
+
+// Same as GetCatalogConfig, returning the error instead of exiting
+func LoadCatalogConfig(awsDynamoDbTable string) (string, aws.Config, error) {
+	letmeContext := GetContextData(GetCurrentContext())
+	if len(awsDynamoDbTable) == 0 {
+		awsDynamoDbTable = letmeContext.AwsDynamoDbTable
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+	return awsDynamoDbTable, cfg, err
+}
+
+// Return every item of the catalog table with all their attributes, sorted by name. The table is scanned in the
+// given number of segments, see ScanTable.
+func ScanCatalogItems(awsDynamoDbTable string, cfg aws.Config, segments int) ([]map[string]dynamodbTypes.AttributeValue, error) {
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(awsDynamoDbTable),
+		ConsistentRead: aws.Bool(true),
+	}
+	items, err := ScanTable(cfg, input, segments)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return CatalogItemName(items[i]) < CatalogItemName(items[j])
+	})
+	return items, nil
+}
+
+// Maximum number of segments a scan can be split in through 'dynamodb_scan_segments'. Every segment is scanned by its
+// own goroutine, more segments only add requests competing for the read capacity of an account catalog.
+const MaxScanSegments = 64
+
+// Attributes of the catalog items used by 'list' and the account picker
+var ListAttributes = []string{"name", "region", "roles", "tags"}
+
+// Scan a whole DynamoDB table, following LastEvaluatedKey until the last page. More than one segment splits the scan
+// in that many segments which are scanned in parallel, up to MaxScanSegments. Contexts set the number of segments
+// with 'dynamodb_scan_segments'.
+func ScanTable(cfg aws.Config, input *dynamodb.ScanInput, segments int) ([]map[string]dynamodbTypes.AttributeValue, error) {
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	segments = min(max(segments, 1), MaxScanSegments)
+
+	segmentItems := make([][]map[string]dynamodbTypes.AttributeValue, segments)
+	segmentErrors := make([]error, segments)
+	var wg sync.WaitGroup
+	for segment := 0; segment < segments; segment++ {
+		segmentInput := *input
+		if segments > 1 {
+			segmentInput.Segment = aws.Int32(int32(segment))
+			segmentInput.TotalSegments = aws.Int32(int32(segments))
+		}
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			paginator := dynamodb.NewScanPaginator(sesAwsDynamoDb, &segmentInput)
+			for paginator.HasMorePages() {
+				page, err := paginator.NextPage(context.TODO())
+				if err != nil {
+					segmentErrors[segment] = err
+					return
+				}
+				segmentItems[segment] = append(segmentItems[segment], page.Items...)
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	var items []map[string]dynamodbTypes.AttributeValue
+	for segment := range segmentItems {
+		if segmentErrors[segment] != nil {
+			return nil, segmentErrors[segment]
+		}
+		items = append(items, segmentItems[segment]...)
+	}
+	return items, nil
+}
+
+// Only fetch the given attributes on a scan, attribute names are passed as placeholders since many of them, such as
+// 'name', are DynamoDB reserved words
+func projectAttributes(input *dynamodb.ScanInput, attributes []string) {
+	if len(attributes) == 0 {
+		return
+	}
+	var projection []string
+	for i, attribute := range attributes {
+		placeholder := fmt.Sprintf("#p%v", i)
+		input.ExpressionAttributeNames[placeholder] = attribute
+		projection = append(projection, placeholder)
+	}
+	input.ProjectionExpression = aws.String(strings.Join(projection, ", "))
+}
+
+// Return the name attribute of a catalog item
+func CatalogItemName(item map[string]dynamodbTypes.AttributeValue) string {
+	if name, ok := item["name"].(*dynamodbTypes.AttributeValueMemberS); ok {
+		return name.Value
+	}
+	return ""
+}
+
+// Maximum number of requests dynamodb accepts on a single BatchWriteItem call
+const batchWriteSize = 25
+
+// Maximum number of times unprocessed items of a batch write are sent again
+const batchWriteRetries = 8
+
+// Put and delete catalog items in batches, see BatchWriteItems
+func BatchWriteAccountItems(awsDynamoDbTable string, cfg aws.Config, puts []map[string]dynamodbTypes.AttributeValue, deletes []string) error {
+	var keys []map[string]dynamodbTypes.AttributeValue
+	for _, accountName := range deletes {
+		keys = append(keys, map[string]dynamodbTypes.AttributeValue{"name": &dynamodbTypes.AttributeValueMemberS{Value: accountName}})
+	}
+	return BatchWriteItems(awsDynamoDbTable, cfg, puts, keys)
+}
+
+// Put items and delete the items with the given keys in batches. Items dynamodb leaves unprocessed, usually because
+// of throttling, are sent again with an exponential backoff.
+func BatchWriteItems(awsDynamoDbTable string, cfg aws.Config, puts []map[string]dynamodbTypes.AttributeValue, deletes []map[string]dynamodbTypes.AttributeValue) error {
+	var requests []dynamodbTypes.WriteRequest
+	for _, item := range puts {
+		requests = append(requests, dynamodbTypes.WriteRequest{PutRequest: &dynamodbTypes.PutRequest{Item: item}})
+	}
+	for _, key := range deletes {
+		requests = append(requests, dynamodbTypes.WriteRequest{DeleteRequest: &dynamodbTypes.DeleteRequest{Key: key}})
+	}
+
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	for start := 0; start < len(requests); start += batchWriteSize {
+		pending := requests[start:min(start+batchWriteSize, len(requests))]
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > batchWriteRetries {
+				return fmt.Errorf("letme: %v items were left unprocessed by DynamoDB after %v retries", len(pending), batchWriteRetries)
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(50<<attempt) * time.Millisecond)
+			}
+			resp, err := sesAwsDynamoDb.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]dynamodbTypes.WriteRequest{awsDynamoDbTable: pending},
+			})
+			if err != nil {
+				return err
+			}
+			pending = resp.UnprocessedItems[awsDynamoDbTable]
+		}
+	}
+	return nil
+}
+
+// Formats the catalog can be exported to and imported from
+var CatalogFormats = []string{"json", "yaml", "csv"}
+
+// Encode catalog items with all their attributes. Csv files have a column per attribute, numbers, booleans, lists
+// and maps are written as json. Strings are written as they are, unless they would be read back as json, such as
+// '123456789012' or 'true', which are written as quoted json strings.
+func EncodeCatalog(items []map[string]dynamodbTypes.AttributeValue, format string) ([]byte, error) {
+	var records []map[string]interface{}
+	if err := attributevalue.UnmarshalListOfMaps(items, &records); err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []map[string]interface{}{}
+	}
+
+	switch format {
+	case "json":
+		return json.MarshalIndent(records, "", " ")
+	case "yaml":
+		return yaml.Marshal(records)
+	case "csv":
+		columns := []string{"name"}
+		for _, record := range records {
+			for column := range record {
+				if !slices.Contains(columns, column) {
+					columns = append(columns, column)
+				}
+			}
+		}
+		sort.Strings(columns[1:])
+
+		var content bytes.Buffer
+		writer := csv.NewWriter(&content)
+		writer.Write(columns)
+		for _, record := range records {
+			row := make([]string, len(columns))
+			for i, column := range columns {
+				switch value := record[column].(type) {
+				case nil:
+				case string:
+					row[i] = value
+					if len(value) == 0 || json.Valid([]byte(value)) {
+						cell, err := json.Marshal(value)
+						if err != nil {
+							return nil, err
+						}
+						row[i] = string(cell)
+					}
+				default:
+					cell, err := json.Marshal(value)
+					if err != nil {
+						return nil, err
+					}
+					row[i] = string(cell)
+				}
+			}
+			writer.Write(row)
+		}
+		writer.Flush()
+		return content.Bytes(), writer.Error()
+	}
+	return nil, fmt.Errorf("letme: unknown catalog format '%s', use one of %s", format, strings.Join(CatalogFormats, ", "))
+}
+
+// Decode catalog items encoded by EncodeCatalog. Csv cells holding json, quoted strings included, are decoded as
+// such, other cells are strings and empty cells are left out of the item.
+func DecodeCatalog(content []byte, format string) ([]map[string]dynamodbTypes.AttributeValue, error) {
+	var records []map[string]interface{}
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(content, &records)
+	case "yaml":
+		err = yaml.Unmarshal(content, &records)
+	case "csv":
+		var rows [][]string
+		rows, err = csv.NewReader(bytes.NewReader(content)).ReadAll()
+		for i := 1; err == nil && i < len(rows); i++ {
+			record := make(map[string]interface{})
+			for j, cell := range rows[i] {
+				var value interface{}
+				switch {
+				case len(cell) == 0:
+					continue
+				case json.Unmarshal([]byte(cell), &value) == nil:
+					record[rows[0][j]] = value
+				default:
+					record[rows[0][j]] = cell
+				}
+			}
+			records = append(records, record)
+		}
+	default:
+		return nil, fmt.Errorf("letme: unknown catalog format '%s', use one of %s", format, strings.Join(CatalogFormats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("letme: unable to parse the catalog: %v", err)
+	}
+
+	items := make([]map[string]dynamodbTypes.AttributeValue, 0, len(records))
+	for _, record := range records {
+		item, err := attributevalue.MarshalMap(record)
+		if err != nil {
+			return nil, err
+		}
+		if len(CatalogItemName(item)) == 0 {
+			return nil, fmt.Errorf("letme: every catalog item must have a name")
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Return the catalog format of a file from its extension, json is used by default
+func CatalogFormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".csv":
+		return "csv"
+	}
+	return "json"
+}
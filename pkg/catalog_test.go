@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Exporting the catalog and importing it back must keep every attribute with its type
+func TestCatalogRoundTrip(t *testing.T) {
+	items := []map[string]dynamodbTypes.AttributeValue{
+		{
+			"name":        &dynamodbTypes.AttributeValueMemberS{Value: "acct"},
+			"id":          &dynamodbTypes.AttributeValueMemberS{Value: "123456789012"},
+			"description": &dynamodbTypes.AttributeValueMemberS{Value: "true"},
+			"quoted":      &dynamodbTypes.AttributeValueMemberS{Value: `"quoted, with a comma"`},
+			"list_text":   &dynamodbTypes.AttributeValueMemberS{Value: `["a"]`},
+			"empty":       &dynamodbTypes.AttributeValueMemberS{Value: ""},
+			"count":       &dynamodbTypes.AttributeValueMemberN{Value: "5"},
+			"enabled":     &dynamodbTypes.AttributeValueMemberBOOL{Value: false},
+			"region": &dynamodbTypes.AttributeValueMemberL{Value: []dynamodbTypes.AttributeValue{
+				&dynamodbTypes.AttributeValueMemberS{Value: "eu-west-1"},
+			}},
+			"session_tags": &dynamodbTypes.AttributeValueMemberM{Value: map[string]dynamodbTypes.AttributeValue{
+				"team": &dynamodbTypes.AttributeValueMemberS{Value: "7"},
+			}},
+		},
+		{
+			"name": &dynamodbTypes.AttributeValueMemberS{Value: "42"},
+			"id":   &dynamodbTypes.AttributeValueMemberS{Value: "plain text"},
+		},
+	}
+
+	for _, format := range CatalogFormats {
+		content, err := EncodeCatalog(items, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		decoded, err := DecodeCatalog(content, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(decoded, items) {
+			t.Errorf("%s: items changed on a round trip:\n%s\n%v", format, content, decoded)
+		}
+	}
+}
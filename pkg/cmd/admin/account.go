@@ -0,0 +1,307 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage accounts of the catalog.",
+	Long: `Add, update, delete and get the accounts stored on the DynamoDB table.
+Items are validated before being written: role arns, region names, tags and a non
+empty role chain. Writes are conditional, so edits made by someone else in the
+meantime are never overwritten.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var accountAddCmd = &cobra.Command{
+	Use:   "add [account]",
+	Short: "Add an account to the catalog.",
+	Long: `Add an account to the catalog from flags, from a json or yaml file with '--file', or both.
+The file holds the item attributes as they are stored in DynamoDB, see 'docs/dynamodb_structure.json'.
+Flags take precedence over the file. Fails if the account already exists.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		table, cfg := adminSession(cmd)
+
+		item := make(map[string]dynamodbTypes.AttributeValue)
+		for key, value := range accountItemChanges(cmd) {
+			if value != nil {
+				item[key] = value
+			}
+		}
+		accountName := accountItemName(item, args)
+		item["name"] = &dynamodbTypes.AttributeValueMemberS{Value: accountName}
+
+		validateAccountItem(item)
+		utils.CheckAndReturnError(utils.PutNewAccountItem(table, cfg, item))
+		utils.CheckAndReturnError(utils.SyncTagIndexItems(table, cfg, accountName, nil, utils.CatalogItemTags(item)))
+		fmt.Println("letme: added account '" + accountName + "' to DynamoDB table '" + table + "'.")
+	},
+}
+
+var accountUpdateCmd = &cobra.Command{
+	Use:   "update [account]",
+	Short: "Update an account of the catalog.",
+	Long: `Change the attributes of an account given as flags or on a json or yaml file with '--file'.
+Attributes not given are kept, empty flag values remove the attribute.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeCatalogAccounts,
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		table, cfg := adminSession(cmd)
+
+		changes := accountItemChanges(cmd)
+		accountName := accountItemName(changes, args)
+		delete(changes, "name")
+
+		previous, err := utils.GetAccountItem(table, cfg, accountName)
+		utils.CheckAndReturnError(err)
+		if previous == nil {
+			utils.CheckAndReturnError(fmt.Errorf("letme: account '%s' does not exist on DynamoDB table '%s'", accountName, table))
+		}
+
+		// only send the attributes which really change
+		item := make(map[string]dynamodbTypes.AttributeValue)
+		for key, value := range previous {
+			item[key] = value
+		}
+		for key, value := range changes {
+			if reflect.DeepEqual(previous[key], value) {
+				delete(changes, key)
+				continue
+			}
+			if value == nil {
+				delete(item, key)
+			} else {
+				item[key] = value
+			}
+		}
+		if len(changes) == 0 {
+			fmt.Println("letme: nothing to update on account '" + accountName + "'.")
+			os.Exit(0)
+		}
+
+		validateAccountItem(item)
+		utils.CheckAndReturnError(utils.UpdateAccountItem(table, cfg, previous, changes))
+		if _, ok := changes["tags"]; ok {
+			utils.CheckAndReturnError(utils.SyncTagIndexItems(table, cfg, accountName, utils.CatalogItemTags(previous), utils.CatalogItemTags(item)))
+		}
+		fmt.Println("letme: updated account '" + accountName + "' on DynamoDB table '" + table + "'.")
+	},
+}
+
+var accountDeleteCmd = &cobra.Command{
+	Use:               "delete account",
+	Aliases:           []string{"rm"},
+	Short:             "Delete an account from the catalog.",
+	Long:              `Delete an account from the DynamoDB table. Local profiles of the account are kept, use 'letme remove' for them.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeCatalogAccounts,
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		table, cfg := adminSession(cmd)
+		previous, err := utils.GetAccountItem(table, cfg, args[0])
+		utils.CheckAndReturnError(err)
+		utils.CheckAndReturnError(utils.DeleteAccountItem(table, cfg, args[0]))
+		utils.CheckAndReturnError(utils.SyncTagIndexItems(table, cfg, args[0], utils.CatalogItemTags(previous), nil))
+		fmt.Println("letme: deleted account '" + args[0] + "' from DynamoDB table '" + table + "'.")
+	},
+}
+
+var accountGetCmd = &cobra.Command{
+	Use:               "get account",
+	Short:             "Show an account of the catalog.",
+	Long:              `Show every attribute of an account stored on the DynamoDB table, including the ones letme does not use.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeCatalogAccounts,
+	Run: func(cmd *cobra.Command, args []string) {
+		output, err := cmd.Flags().GetString("output")
+		utils.CheckAndReturnError(err)
+		table, cfg := adminSession(cmd)
+
+		item, err := utils.GetAccountItem(table, cfg, args[0])
+		utils.CheckAndReturnError(err)
+		if item == nil {
+			fmt.Println("letme: account '" + args[0] + "' does not exist on DynamoDB table '" + table + "'.")
+			os.Exit(1)
+		}
+		var attributes map[string]interface{}
+		utils.CheckAndReturnError(attributevalue.UnmarshalMap(item, &attributes))
+
+		switch output {
+		case "json":
+			jsonData, err := json.MarshalIndent(attributes, "", " ")
+			utils.CheckAndReturnError(err)
+			fmt.Println(string(jsonData))
+		case "yaml":
+			yamlData, err := yaml.Marshal(attributes)
+			utils.CheckAndReturnError(err)
+			fmt.Print(string(yamlData))
+		default:
+			utils.CheckAndReturnError(fmt.Errorf("letme: unknown output format '%s', use one of json, yaml", output))
+		}
+	},
+}
+
+// Return the item attributes given through '--file' and the flags, flags take precedence. Empty flag values are
+// returned as nil so they remove the attribute.
+func accountItemChanges(cmd *cobra.Command) map[string]dynamodbTypes.AttributeValue {
+	changes := make(map[string]dynamodbTypes.AttributeValue)
+	if file, _ := cmd.Flags().GetString("file"); len(file) > 0 {
+		attributes, err := utils.ReadAccountItemFile(file)
+		utils.CheckAndReturnError(err)
+		changes = attributes
+	}
+
+	for flag, attribute := range map[string]string{"region": "region", "role": "role", "tag": "tags", "session-policy-arn": "session_policy_arns", "transitive-tag-key": "transitive_tag_keys"} {
+		if cmd.Flags().Changed(flag) {
+			values, _ := cmd.Flags().GetStringArray(flag)
+			changes[attribute] = listAttribute(values)
+		}
+	}
+	for flag, attribute := range map[string]string{"external-id": "external_id", "source-identity": "source_identity", "default-role": "default_role", "description": "description"} {
+		if cmd.Flags().Changed(flag) {
+			value, _ := cmd.Flags().GetString(flag)
+			changes[attribute] = nil
+			if len(value) > 0 {
+				changes[attribute] = &dynamodbTypes.AttributeValueMemberS{Value: value}
+			}
+		}
+	}
+	if cmd.Flags().Changed("session-tag") {
+		sessionTags, _ := cmd.Flags().GetStringArray("session-tag")
+		tags := make(map[string]dynamodbTypes.AttributeValue)
+		for _, tag := range sessionTags {
+			key, value, found := strings.Cut(tag, "=")
+			if !found || len(key) == 0 {
+				utils.CheckAndReturnError(fmt.Errorf("letme: session tag '%s' does not follow the 'key=value' format", tag))
+			}
+			tags[key] = &dynamodbTypes.AttributeValueMemberS{Value: value}
+		}
+		changes["session_tags"] = nil
+		if len(tags) > 0 {
+			changes["session_tags"] = &dynamodbTypes.AttributeValueMemberM{Value: tags}
+		}
+	}
+	return changes
+}
+
+// Return a list attribute, or nil to remove the attribute if there are no values
+func listAttribute(values []string) dynamodbTypes.AttributeValue {
+	var list []dynamodbTypes.AttributeValue
+	for _, value := range values {
+		if len(value) > 0 {
+			list = append(list, &dynamodbTypes.AttributeValueMemberS{Value: value})
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &dynamodbTypes.AttributeValueMemberL{Value: list}
+}
+
+// Return the account name given as argument or on the item file, both must match if given
+func accountItemName(item map[string]dynamodbTypes.AttributeValue, args []string) string {
+	var fileName string
+	if name, ok := item["name"].(*dynamodbTypes.AttributeValueMemberS); ok {
+		fileName = name.Value
+	}
+	switch {
+	case len(args) > 0 && len(fileName) > 0 && args[0] != fileName:
+		utils.CheckAndReturnError(fmt.Errorf("letme: account '%s' does not match the name '%s' on the file", args[0], fileName))
+	case len(args) > 0:
+		return args[0]
+	case len(fileName) == 0:
+		utils.CheckAndReturnError(fmt.Errorf("letme: specify the account name as argument or on the file"))
+	}
+	return fileName
+}
+
+// Exit listing the problems of a catalog item if letme would not be able to obtain it
+func validateAccountItem(item map[string]dynamodbTypes.AttributeValue) {
+	account, err := utils.AccountFromItem(item)
+	utils.CheckAndReturnError(err)
+	problems := utils.ValidateAccountConfig(account)
+	if len(problems) == 0 {
+		return
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	os.Exit(1)
+}
+
+// Complete account names with the names on the catalog table. Names are cached like the ones of the current context,
+// under the table name since the catalog is not filtered by the context tags. Nothing is completed when the letme
+// config can not be read, completion never exits.
+func completeCatalogAccounts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if _, err := os.Stat(utils.GetHomeDirectory() + "/.letme/letme-config"); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if !slices.Contains(utils.GetAvalaibleContexts(), utils.GetCurrentContext()) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	table, _ := cmd.Flags().GetString("table")
+	table, cfg, err := utils.LoadCatalogConfig(table)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cacheKey := "table:" + table
+	names, fresh := utils.GetCompletionCache(cacheKey)
+	if !fresh {
+		if accounts, err := utils.ScanAccountNames(table, []string{}, cfg, adminScanSegments()); err == nil {
+			utils.UpdateCompletionCache(cacheKey, accounts)
+			names, _ = utils.GetCompletionCache(cacheKey)
+		}
+	}
+
+	var completions []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) {
+			completions = append(completions, name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	AdminCmd.AddCommand(accountCmd)
+	accountCmd.AddCommand(accountAddCmd)
+	accountCmd.AddCommand(accountUpdateCmd)
+	accountCmd.AddCommand(accountDeleteCmd)
+	accountCmd.AddCommand(accountGetCmd)
+
+	for _, command := range []*cobra.Command{accountAddCmd, accountUpdateCmd} {
+		command.Flags().StringP("file", "f", "", "json or yaml file with the item attributes")
+		command.Flags().StringArray("region", []string{}, "region of the account, the first one is the main region, can be repeated")
+		command.Flags().StringArray("role", []string{}, "role arn of the role chain in assume order, can be repeated")
+		command.Flags().StringArray("tag", []string{}, "tag of the account, can be repeated")
+		command.Flags().String("external-id", "", "external id sent when assuming the roles of the account")
+		command.Flags().String("source-identity", "", "source identity set when assuming the first role")
+		command.Flags().StringArray("session-tag", []string{}, "session tag in 'key=value' format, can be repeated")
+		command.Flags().StringArray("transitive-tag-key", []string{}, "session tag key kept through role chaining, can be repeated")
+		command.Flags().StringArray("session-policy-arn", []string{}, "arn of a managed policy used as session policy, can be repeated")
+		command.Flags().String("default-role", "", "named role assumed when no role is given")
+		command.Flags().String("description", "", "description of the account")
+	}
+	accountGetCmd.Flags().StringP("output", "o", "json", "output results in specific format (json|yaml)")
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	utils "github.com/lockedinspace/letme/pkg"
+	letme "github.com/lockedinspace/letme/pkg/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+var AdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Manage the DynamoDB account catalog.",
+	Long: `Create, update and remove the accounts stored on the DynamoDB table of the current context.
+Use '--table' to work on another table with the source profile of the current context.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// Return the catalog table and the aws config of the source profile of the current context. The letme config is
+// only checked here, so commands which do not reach DynamoDB also run without one.
+func adminSession(cmd *cobra.Command) (string, aws.Config) {
+	utils.ConfigFileHealth()
+	table, _ := cmd.Flags().GetString("table")
+	return utils.GetCatalogConfig(table)
+}
+
+// Return the number of segments the catalog is scanned in, set with 'dynamodb_scan_segments' on the current context
+func adminScanSegments() int {
+	return utils.GetContextData(utils.GetCurrentContext()).AwsDynamoDbScanSegments
+}
+
+func init() {
+	letme.RootCmd.AddCommand(AdminCmd)
+	AdminCmd.PersistentFlags().String("table", "", "DynamoDB table to manage instead of the one of the current context")
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/lockedinspace/letme/docs"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var initTableCmd = &cobra.Command{
+	Use:   "init-table name",
+	Short: "Create a DynamoDB table for the account catalog.",
+	Long: `Create a DynamoDB table keyed by account name, billed on demand, and seed it with the
+example account from 'docs/dynamodb_structure.json'. Use '--tag-index' to also create the
+'<name>` + utils.TagIndexTableSuffix + `' table used to look up accounts by tag and '--point-in-time-recovery'
+to enable continuous backups.
+With '--print-cloudformation' or '--print-terraform' the equivalent infrastructure as
+code is printed instead and nothing is created.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		tagIndex, _ := cmd.Flags().GetBool("tag-index")
+		pointInTimeRecovery, _ := cmd.Flags().GetBool("point-in-time-recovery")
+		seed, _ := cmd.Flags().GetBool("seed")
+		printCloudFormation, _ := cmd.Flags().GetBool("print-cloudformation")
+		printTerraform, _ := cmd.Flags().GetBool("print-terraform")
+
+		switch {
+		case printCloudFormation && printTerraform:
+			fmt.Println("letme: '--print-cloudformation' cannot be used along with '--print-terraform'.")
+			os.Exit(1)
+		case printCloudFormation:
+			fmt.Print(utils.CatalogTableCloudFormation(args[0], tagIndex, pointInTimeRecovery))
+			os.Exit(0)
+		case printTerraform:
+			fmt.Print(utils.CatalogTableTerraform(args[0], tagIndex, pointInTimeRecovery))
+			os.Exit(0)
+		}
+
+		_, cfg := adminSession(cmd)
+		fmt.Println("letme: creating DynamoDB table '" + args[0] + "', this may take a while.")
+		utils.CheckAndReturnError(utils.CreateCatalogTable(args[0], cfg, tagIndex, pointInTimeRecovery))
+		fmt.Println("letme: created DynamoDB table '" + args[0] + "'.")
+
+		if seed {
+			var attributes map[string]interface{}
+			utils.CheckAndReturnError(json.Unmarshal(docs.DynamoDbStructure, &attributes))
+			item, err := attributevalue.MarshalMap(attributes)
+			utils.CheckAndReturnError(err)
+			utils.CheckAndReturnError(utils.PutNewAccountItem(args[0], cfg, item))
+			utils.CheckAndReturnError(utils.SyncTagIndexItems(args[0], cfg, utils.CatalogItemName(item), nil, utils.CatalogItemTags(item)))
+			fmt.Printf("letme: added example account '%v' to DynamoDB table '%s'.\n", attributes["name"], args[0])
+		}
+		fmt.Println("letme: set 'dynamodb_table' to '" + args[0] + "' on your context with 'letme config update-context' to use it.")
+	},
+}
+
+func init() {
+	AdminCmd.AddCommand(initTableCmd)
+	initTableCmd.Flags().Bool("tag-index", false, "also create the tag index table used to look up accounts by tag")
+	initTableCmd.Flags().Bool("point-in-time-recovery", false, "enable point in time recovery on the table")
+	initTableCmd.Flags().Bool("seed", true, "add the example account from 'docs/dynamodb_structure.json'")
+	initTableCmd.Flags().Bool("print-cloudformation", false, "print an equivalent cloudformation template instead of creating the table")
+	initTableCmd.Flags().Bool("print-terraform", false, "print an equivalent terraform configuration instead of creating the table")
+}
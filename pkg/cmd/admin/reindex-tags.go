@@ -0,0 +1,39 @@
+package admin
+
+import (
+	"fmt"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var reindexTagsCmd = &cobra.Command{
+	Use:   "reindex-tags",
+	Short: "Rebuild the tag index table of the catalog.",
+	Long: `Write an inverted tag item for every tag of every account and delete the ones of tags
+no longer used, so the tag index table matches the catalog. The inverted tag items are
+kept on their own table, named after the catalog table with the '` + utils.TagIndexTableSuffix + `' suffix,
+which is created if it does not exist yet. Run it once on an existing table, then set
+'dynamodb_tag_index = true' on the contexts using the table so tags are queried instead
+of scanned.
+'letme admin account' and 'letme catalog import' keep the items in sync afterwards.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		table, cfg := adminSession(cmd)
+		hasTagIndex, err := utils.HasTagIndex(table, cfg)
+		utils.CheckAndReturnError(err)
+		if !hasTagIndex {
+			fmt.Println("letme: creating DynamoDB table '" + utils.TagIndexTable(table) + "', this may take a while.")
+			utils.CheckAndReturnError(utils.CreateTagIndexTable(table, cfg, false))
+		}
+
+		written, deleted, err := utils.RebuildTagIndex(table, cfg, adminScanSegments())
+		utils.CheckAndReturnError(err)
+		fmt.Printf("letme: wrote %v and deleted %v inverted tag item(s) on DynamoDB table '%s'.\n", written, deleted, utils.TagIndexTable(table))
+	},
+}
+
+func init() {
+	AdminCmd.AddCommand(reindexTagsCmd)
+}
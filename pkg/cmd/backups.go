@@ -0,0 +1,116 @@
+package letme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "Manage backups of your AWS files.",
+	Long: `Before changing '$HOME/.aws/credentials' or '$HOME/.aws/config', letme saves
+their previous content on '$HOME/.letme/backups'. Only the most recent backups are kept.
+Use 'letme undo' to revert the last change.
+Backups hold whole credentials files, session tokens and static keys included. They are
+removed by 'letme logout' and can be removed at any time with 'letme backups clear'.`,
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List backups of your AWS files.",
+	Long:    `List the backups of your AWS files, most recent first, along with the letme command which changed them.`,
+	Args:    cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, err := cmd.Flags().GetString("output")
+		utils.CheckAndReturnError(err)
+
+		backups := utils.ListAwsFileBackups()
+		switch output {
+		case "text":
+			if len(backups) == 0 {
+				fmt.Println("letme: no backups found.")
+				os.Exit(1)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "ID:\tCREATED:\tFILES:\tCOMMAND:")
+			fmt.Fprintln(w, "---\t--------\t------\t--------")
+			for _, backup := range backups {
+				var files []string
+				for _, file := range backup.Files {
+					if slices.Contains(backup.Absent, file) {
+						file += " (absent)"
+					}
+					files = append(files, file)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", backup.Id, backup.Created.Local().Format("2006-01-02 15:04:05"), strings.Join(files, ","), backup.Command)
+			}
+			w.Flush()
+		case "json":
+			if backups == nil {
+				backups = []utils.AwsFileBackup{}
+			}
+			jsonData, err := json.MarshalIndent(backups, "", " ")
+			utils.CheckAndReturnError(err)
+			fmt.Println(string(jsonData))
+		}
+	},
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a backup of your AWS files.",
+	Long: `Restore your AWS files from a backup listed by 'letme backups list'. Files which did
+not exist when the backup was taken are deleted. The files being replaced are backed
+up first, so the restore can be reverted with 'letme undo'.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var ids []string
+		for _, backup := range utils.ListAwsFileBackups() {
+			ids = append(ids, backup.Id)
+		}
+		return filterCompletions(ids, toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		backup, err := utils.GetAwsFileBackup(args[0])
+		utils.CheckAndReturnError(err)
+		utils.CheckAndReturnError(utils.RestoreAwsFileBackup(backup, true))
+		fmt.Println("letme: restored " + strings.Join(backup.Files, " and ") + " files from backup '" + backup.Id + "'.")
+	},
+}
+
+var backupsClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every backup of your AWS files.",
+	Long: `Remove every backup on '$HOME/.letme/backups', along with the credentials they hold.
+Changes made before can no longer be reverted with 'letme undo'.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		if utils.DryRun {
+			fmt.Printf("letme: dry run, would remove %v backup(s) of your AWS files.\n", len(utils.ListAwsFileBackups()))
+			os.Exit(0)
+		}
+		cleared, err := utils.ClearAwsFileBackups()
+		utils.CheckAndReturnError(err)
+		fmt.Printf("letme: removed %v backup(s) of your AWS files.\n", cleared)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(backupsCmd)
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.AddCommand(backupsClearCmd)
+	backupsListCmd.Flags().StringP("output", "o", "text", "output results in specific format (text|json)")
+}
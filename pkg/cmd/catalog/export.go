@@ -0,0 +1,39 @@
+package catalog
+
+import (
+	"os"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export every account of the catalog.",
+	Long: `Write every item of the catalog to stdout with all its attributes, including the ones
+letme does not use such as 'id' and 'description'. Items are sorted by name so exports
+can be kept on git and diffed. Csv exports have a column per attribute, lists and maps
+are written as json.`,
+	Example: `  letme catalog export --format yaml > catalog.yaml`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, err := cmd.Flags().GetString("format")
+		utils.CheckAndReturnError(err)
+		table, cfg := catalogSession(cmd)
+
+		items, err := utils.ScanCatalogItems(table, cfg, catalogScanSegments())
+		utils.CheckAndReturnError(err)
+		content, err := utils.EncodeCatalog(items, format)
+		utils.CheckAndReturnError(err)
+		if format == "json" {
+			content = append(content, '\n')
+		}
+		os.Stdout.Write(content)
+	},
+}
+
+func init() {
+	CatalogCmd.AddCommand(exportCmd)
+	exportCmd.Flags().String("format", "json", "export the catalog in specific format (json|yaml|csv)")
+	exportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(utils.CatalogFormats, cobra.ShellCompDirectiveNoFileComp))
+}
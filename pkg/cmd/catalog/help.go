@@ -0,0 +1,38 @@
+package catalog
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	utils "github.com/lockedinspace/letme/pkg"
+	letme "github.com/lockedinspace/letme/pkg/cmd"
+
+	"github.com/spf13/cobra"
+)
+
+var CatalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Export and import the DynamoDB account catalog.",
+	Long: `Work with the whole account catalog stored on the DynamoDB table of the current context,
+e.g. to keep it on git and sync it to DynamoDB from CI.
+Use '--table' to work on another table with the source profile of the current context.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// Return the catalog table and the aws config of the source profile of the current context. The letme config is
+// only checked here, so commands which do not reach DynamoDB also run without one.
+func catalogSession(cmd *cobra.Command) (string, aws.Config) {
+	utils.ConfigFileHealth()
+	table, _ := cmd.Flags().GetString("table")
+	return utils.GetCatalogConfig(table)
+}
+
+// Return the number of segments the catalog is scanned in, set with 'dynamodb_scan_segments' on the current context
+func catalogScanSegments() int {
+	return utils.GetContextData(utils.GetCurrentContext()).AwsDynamoDbScanSegments
+}
+
+func init() {
+	letme.RootCmd.AddCommand(CatalogCmd)
+	CatalogCmd.PersistentFlags().String("table", "", "DynamoDB table to use instead of the one of the current context")
+}
@@ -0,0 +1,163 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import file",
+	Short: "Import accounts to the catalog.",
+	Long: `Write the accounts of a json, yaml or csv file, as written by 'letme catalog export', to the catalog.
+Items are validated and compared with the ones on DynamoDB first, the accounts to add, change and
+delete are shown before anything is written. Items on the file replace the whole item on DynamoDB.
+Accounts not on the file are only deleted with '--prune'.
+Writes are batched, items DynamoDB leaves unprocessed are retried.`,
+	Example: `  letme catalog import catalog.yaml --dry-run
+  letme catalog import catalog.yaml --prune --yes`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		prune, _ := cmd.Flags().GetBool("prune")
+		yes, _ := cmd.Flags().GetBool("yes")
+		if len(format) == 0 {
+			format = utils.CatalogFormatFromPath(args[0])
+		}
+
+		content, err := os.ReadFile(args[0])
+		utils.CheckAndReturnError(err)
+		items, err := utils.DecodeCatalog(content, format)
+		utils.CheckAndReturnError(err)
+		validateCatalogItems(items)
+
+		table, cfg := catalogSession(cmd)
+		current, err := utils.ScanCatalogItems(table, cfg, catalogScanSegments())
+		utils.CheckAndReturnError(err)
+		currentItems := make(map[string]map[string]dynamodbTypes.AttributeValue)
+		for _, item := range current {
+			currentItems[utils.CatalogItemName(item)] = item
+		}
+
+		// preview every change before writing anything
+		var puts []map[string]dynamodbTypes.AttributeValue
+		var deletes []string
+		var added, changed, unchanged int
+		imported := make(map[string]bool)
+		for _, item := range items {
+			accountName := utils.CatalogItemName(item)
+			imported[accountName] = true
+			previous, exists := currentItems[accountName]
+			switch {
+			case !exists:
+				fmt.Println("+ " + accountName)
+				added++
+			case renderItem(previous) != renderItem(item):
+				fmt.Println("~ " + accountName)
+				fmt.Print(utils.UnifiedDiff(renderItem(previous), renderItem(item)))
+				changed++
+			default:
+				unchanged++
+				continue
+			}
+			puts = append(puts, item)
+		}
+		if prune {
+			for _, item := range current {
+				if !imported[utils.CatalogItemName(item)] {
+					fmt.Println("- " + utils.CatalogItemName(item))
+					deletes = append(deletes, utils.CatalogItemName(item))
+				}
+			}
+		}
+		fmt.Printf("letme: %v to add, %v to change, %v to delete, %v unchanged on DynamoDB table '%s'.\n", added, changed, len(deletes), unchanged, table)
+
+		if len(puts)+len(deletes) == 0 || utils.DryRun {
+			os.Exit(0)
+		}
+		if !yes {
+			if !utils.IsTerminal() {
+				utils.CheckAndReturnError(fmt.Errorf("letme: append argument '--yes' to import the catalog without confirmation"))
+			}
+			var answer string
+			fmt.Print("Apply these changes? [y/N]: ")
+			fmt.Scanln(&answer)
+			if !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+				fmt.Println("letme: import cancelled.")
+				os.Exit(1)
+			}
+		}
+
+		utils.CheckAndReturnError(utils.BatchWriteAccountItems(table, cfg, puts, deletes))
+
+		// keep the inverted tag items of the tag index table in sync with the imported tags
+		hasTagIndex, err := utils.HasTagIndex(table, cfg)
+		utils.CheckAndReturnError(err)
+		if hasTagIndex {
+			var tagPuts, tagDeletes []map[string]dynamodbTypes.AttributeValue
+			for _, item := range puts {
+				accountPuts, accountDeletes := utils.TagIndexChanges(utils.CatalogItemName(item), utils.CatalogItemTags(currentItems[utils.CatalogItemName(item)]), utils.CatalogItemTags(item))
+				tagPuts = append(tagPuts, accountPuts...)
+				tagDeletes = append(tagDeletes, accountDeletes...)
+			}
+			for _, accountName := range deletes {
+				_, accountDeletes := utils.TagIndexChanges(accountName, utils.CatalogItemTags(currentItems[accountName]), nil)
+				tagDeletes = append(tagDeletes, accountDeletes...)
+			}
+			utils.CheckAndReturnError(utils.BatchWriteItems(utils.TagIndexTable(table), cfg, tagPuts, tagDeletes))
+		}
+		fmt.Printf("letme: imported %v account(s) and deleted %v account(s) on DynamoDB table '%s'.\n", len(puts), len(deletes), table)
+	},
+}
+
+// Render a catalog item as indented json, so changes can be shown line by line
+func renderItem(item map[string]dynamodbTypes.AttributeValue) string {
+	var attributes map[string]interface{}
+	utils.CheckAndReturnError(attributevalue.UnmarshalMap(item, &attributes))
+	jsonData, err := json.MarshalIndent(attributes, "", " ")
+	utils.CheckAndReturnError(err)
+	return string(jsonData) + "\n"
+}
+
+// Exit listing the problems of every item letme would not be able to obtain, or of repeated account names
+func validateCatalogItems(items []map[string]dynamodbTypes.AttributeValue) {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		accountName := utils.CatalogItemName(item)
+		if seen[accountName] {
+			problems = append(problems, "letme: account '"+accountName+"' is repeated on the file")
+		}
+		seen[accountName] = true
+
+		account, err := utils.AccountFromItem(item)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("letme: account '%s': %v", accountName, err))
+			continue
+		}
+		for _, problem := range utils.ValidateAccountConfig(account) {
+			problems = append(problems, problem.Error())
+		}
+	}
+	if len(problems) == 0 {
+		return
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	os.Exit(1)
+}
+
+func init() {
+	CatalogCmd.AddCommand(importCmd)
+	importCmd.Flags().String("format", "", "format of the file (json|yaml|csv), guessed from the file extension by default")
+	importCmd.Flags().Bool("prune", false, "delete the accounts of the catalog which are not on the file")
+	importCmd.Flags().BoolP("yes", "y", false, "import without asking for confirmation")
+	importCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(utils.CatalogFormats, cobra.ShellCompDirectiveNoFileComp))
+}
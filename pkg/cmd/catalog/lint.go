@@ -0,0 +1,121 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file]",
+	Short: "Check the catalog for broken accounts.",
+	Long: `Check every account of the catalog, or of a file written by 'letme catalog export', for:
+duplicate names, empty or malformed role arns, role chains which loop or come back to an
+account, malformed or unknown regions, missing tags and missing regions, which break 'list'
+and 'obtain'.
+Exits with status 1 if any error is found, or any warning with '--strict', so it can gate
+catalog changes on CI. Json output follows the SARIF layout.`,
+	Example: `  letme catalog lint
+  letme catalog lint catalog.yaml --strict -o json > catalog.sarif`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+		format, _ := cmd.Flags().GetString("format")
+		allowedRegions, _ := cmd.Flags().GetStringArray("allow-region")
+		strict, _ := cmd.Flags().GetBool("strict")
+
+		var items []map[string]dynamodbTypes.AttributeValue
+		var source string
+		if len(args) > 0 {
+			if len(format) == 0 {
+				format = utils.CatalogFormatFromPath(args[0])
+			}
+			content, err := os.ReadFile(args[0])
+			utils.CheckAndReturnError(err)
+			items, err = utils.DecodeCatalog(content, format)
+			utils.CheckAndReturnError(err)
+			source = args[0]
+		} else {
+			table, cfg := catalogSession(cmd)
+			var err error
+			items, err = utils.ScanCatalogItems(table, cfg, catalogScanSegments())
+			utils.CheckAndReturnError(err)
+			source = "dynamodb:" + table
+		}
+
+		findings := utils.LintCatalogItems(items, allowedRegions)
+		var errors, warnings int
+		for _, finding := range findings {
+			if finding.Level == "error" {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+
+		switch output {
+		case "text":
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, finding := range findings {
+				fmt.Fprintln(w, finding.Level+"\t"+finding.RuleId+"\t"+finding.Message)
+			}
+			w.Flush()
+			fmt.Printf("letme: %v error(s) and %v warning(s) on %v account(s) of '%s'.\n", errors, warnings, len(items), source)
+		case "json":
+			jsonData, err := json.MarshalIndent(sarifReport(findings, source), "", " ")
+			utils.CheckAndReturnError(err)
+			fmt.Println(string(jsonData))
+		default:
+			utils.CheckAndReturnError(fmt.Errorf("letme: unknown output format '%s', use one of text, json", output))
+		}
+
+		if errors > 0 || (strict && warnings > 0) {
+			os.Exit(1)
+		}
+	},
+}
+
+// Return the findings of the linter as a SARIF log, locations are the account items of the catalog
+func sarifReport(findings []utils.CatalogLintFinding, source string) map[string]interface{} {
+	var rules []map[string]interface{}
+	for _, rule := range utils.CatalogLintRules {
+		rules = append(rules, map[string]interface{}{
+			"id":                   rule.Id,
+			"shortDescription":     map[string]string{"text": rule.Description},
+			"defaultConfiguration": map[string]string{"level": rule.Level},
+		})
+	}
+	results := []map[string]interface{}{}
+	for _, finding := range findings {
+		results = append(results, map[string]interface{}{
+			"ruleId":  finding.RuleId,
+			"level":   finding.Level,
+			"message": map[string]string{"text": finding.Message},
+			"locations": []map[string]interface{}{{
+				"physicalLocation": map[string]interface{}{"artifactLocation": map[string]string{"uri": source}},
+				"logicalLocations": []map[string]string{{"name": finding.Account, "kind": "object"}},
+			}},
+		})
+	}
+	return map[string]interface{}{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []map[string]interface{}{{
+			"tool":    map[string]interface{}{"driver": map[string]interface{}{"name": "letme", "informationUri": "https://github.com/lockedinspace/letme", "rules": rules}},
+			"results": results,
+		}},
+	}
+}
+
+func init() {
+	CatalogCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringP("output", "o", "text", "output results in specific format (text|json)")
+	lintCmd.Flags().String("format", "", "format of the file (json|yaml|csv), guessed from the file extension by default")
+	lintCmd.Flags().StringArray("allow-region", []string{}, "region to accept besides the aws regions letme knows about, can be repeated")
+	lintCmd.Flags().Bool("strict", false, "exit with status 1 on warnings too")
+}
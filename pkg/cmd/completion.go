@@ -0,0 +1,60 @@
+package letme
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+// Complete the account names of the current context. Names are read from the completion cache, which is only
+// refreshed from DynamoDB once it gets stale, so pressing TAB does not scan the table every time.
+func completeAccountNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if _, err := os.Stat(utils.GetHomeDirectory() + "/.letme/letme-config"); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	currentContext := utils.GetCurrentContext()
+	if !slices.Contains(utils.GetAvalaibleContexts(), currentContext) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	accounts, fresh := utils.GetCompletionCache(currentContext)
+	if !fresh {
+		letmeContext := utils.GetContextData(currentContext)
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+		if err == nil {
+			if accountList, err := utils.ScanAccountNames(letmeContext.AwsDynamoDbTable, letmeContext.Tags, cfg, letmeContext.AwsDynamoDbScanSegments); err == nil {
+				utils.UpdateCompletionCache(currentContext, accountList)
+				accounts, _ = utils.GetCompletionCache(currentContext)
+			}
+		}
+	}
+
+	return filterCompletions(accounts, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Complete the profiles managed by letme in the local aws files
+func completeLocalProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(utils.GetLetmeManagedProfiles(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Keep the completion candidates starting with the text being completed
+func filterCompletions(candidates []string, toComplete string) []string {
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, toComplete) {
+			completions = append(completions, candidate)
+		}
+	}
+	return completions
+}
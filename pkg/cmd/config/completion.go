@@ -0,0 +1,27 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+// Complete the context names available in the letme-config file
+func completeContextNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if _, err := os.Stat(utils.GetHomeDirectory() + "/.letme/letme-config"); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, context := range utils.GetAvalaibleContexts() {
+		if strings.HasPrefix(context, toComplete) {
+			completions = append(completions, context)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -11,12 +11,14 @@ import (
 var SwitchContext = &cobra.Command{
 	Use: "switch-context",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
 		utils.LetmeConfigCreate()
 		utils.ConfigFileHealth()
 	},
-	Short: "Switch to a context.",
-	Long:  `If the context exists, switch to the specified context.`,
-	Args:  cobra.ExactArgs(1),
+	Short:             "Switch to a context.",
+	Long:              `If the context exists, switch to the specified context.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames,
 	Run: func(cmd *cobra.Command, args []string) {
 		contexts := utils.GetAvalaibleContexts()
 		letmeContext := args[0]
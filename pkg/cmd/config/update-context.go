@@ -11,12 +11,14 @@ import (
 var UpdateContext = &cobra.Command{
 	Use: "update-context",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
 		utils.LetmeConfigCreate()
 		utils.ConfigFileHealth()
 	},
-	Short: "Change context values.",
-	Long:  `Interactively update an existing context.`,
-	Args:  cobra.ExactArgs(1),
+	Short:             "Change context values.",
+	Long:              `Interactively update an existing context.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames,
 	Run: func(cmd *cobra.Command, args []string) {
 		contexts := utils.GetAvalaibleContexts()
 		letmeContext := args[0]
@@ -0,0 +1,92 @@
+package letme
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var favCmd = &cobra.Command{
+	Use: "fav",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.ConfigFileHealth()
+	},
+	Short: "Manage favorite accounts.",
+	Long: `List, add or remove favorite accounts for the current context. Favorites are
+listed first by the account picker and can be listed with 'letme list --favorites'.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		currentContext := utils.GetCurrentContext()
+		favorites := utils.GetFavoriteAccounts(currentContext)
+		if len(favorites) == 0 {
+			fmt.Println("letme: no favorite accounts using '" + currentContext + "' context. Add one with 'letme fav add $ACCOUNT_NAME'.")
+			os.Exit(1)
+		}
+		fmt.Println("Favorite accounts using '" + currentContext + "' context:\n")
+		for _, favorite := range favorites {
+			fmt.Println(favorite)
+		}
+	},
+}
+
+var favAddCmd = &cobra.Command{
+	Use:               "add",
+	Short:             "Mark an account as favorite.",
+	Long:              `Mark an account from the current context as favorite.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		// get the current context
+		currentContext := utils.GetCurrentContext()
+		letmeContext := utils.GetContextData(currentContext)
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+		utils.CheckAndReturnError(err)
+		account := utils.GetAccount(letmeContext.AwsDynamoDbTable, cfg, args[0])
+		if len(account.Name) == 0 {
+			fmt.Println("letme: the specified account does not exist in your DynamoDB.")
+			fmt.Println("letme: run 'letme list' to list available accounts.")
+			os.Exit(1)
+		}
+
+		if utils.SetFavoriteAccount(args[0], true) {
+			fmt.Println("letme: added '" + args[0] + "' to your favorite accounts.")
+		} else {
+			fmt.Println("letme: '" + args[0] + "' is already a favorite account.")
+		}
+	},
+}
+
+var favRemoveCmd = &cobra.Command{
+	Use:     "rm",
+	Aliases: []string{"remove"},
+	Short:   "Unmark a favorite account.",
+	Long:    `Remove an account of the current context from your favorite accounts.`,
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return filterCompletions(utils.GetFavoriteAccounts(utils.GetCurrentContext()), toComplete), cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		if utils.SetFavoriteAccount(args[0], false) {
+			fmt.Println("letme: removed '" + args[0] + "' from your favorite accounts.")
+		} else {
+			fmt.Println("letme: '" + args[0] + "' is not a favorite account.")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(favCmd)
+	favCmd.AddCommand(favAddCmd)
+	favCmd.AddCommand(favRemoveCmd)
+}
@@ -56,6 +56,7 @@ func getVersions() string {
 func init() {
 	var Version bool
 	RootCmd.PersistentFlags().BoolVarP(&Version, "version", "v", false, "list current version for letme")
+	RootCmd.PersistentFlags().BoolVar(&utils.DryRun, "dry-run", false, "show the sts calls and the changes to your files without making them")
 }
 
 func Execute() {
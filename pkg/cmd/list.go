@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	utils "github.com/lockedinspace/letme/pkg"
@@ -16,7 +17,13 @@ var listCmd = &cobra.Command{
 		utils.ConfigFileHealth()
 	},
 	Short: "List accounts.",
-	Long:  `List all the AWS accounts and their main region.`,
+	Long: `List all the AWS accounts and their main region.
+Use '--filter' with a filter expression to list only some accounts. Terms are tags, 'key=value'
+tags included, or 'name=' and 'region=' matches. Values can be exact, globs using '*' and '?',
+or regular expressions between slashes. Terms are combined with AND, OR, NOT and parentheses.
+The parts DynamoDB can evaluate are sent along with the scan, the rest is checked by letme.`,
+	Example: `  letme list --filter 'env=prod AND (team=payments OR team=risk) AND NOT deprecated'
+  letme list --filter 'name=prod-* OR region=/^eu-/'`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// get the current context
 		currentContext := utils.GetCurrentContext()
@@ -25,6 +32,8 @@ var listCmd = &cobra.Command{
 		utils.CheckAndReturnError(err)
 		output, err := cmd.Flags().GetString("output")
 		utils.CheckAndReturnError(err)
+		favorites, err := cmd.Flags().GetBool("favorites")
+		utils.CheckAndReturnError(err)
 
 		if len(filterTags) != 0 {
 			letmeContext.Tags = filterTags
@@ -36,7 +45,21 @@ var listCmd = &cobra.Command{
 		// create a new aws session
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
 		utils.CheckAndReturnError(err)
-		tableData := utils.GetTableData(letmeContext.AwsDynamoDbTable, letmeContext.Tags, cfg)
+		tableData := utils.GetTableData(letmeContext.AwsDynamoDbTable, letmeContext.Tags, cfg, utils.ListAttributes...)
+		if len(filterTags) == 0 {
+			utils.UpdateCompletionCache(currentContext, tableData)
+		}
+
+		if favorites {
+			favoriteAccounts := utils.GetFavoriteAccounts(currentContext)
+			var favoriteData []utils.DynamoDbAccountConfig
+			for _, account := range tableData {
+				if slices.Contains(favoriteAccounts, account.Name) {
+					favoriteData = append(favoriteData, account)
+				}
+			}
+			tableData = favoriteData
+		}
 
 		if len(tableData) == 0 {
 			fmt.Println("letme: no items found that matched your filters on DynamoDB Table '" + letmeContext.AwsDynamoDbTable + "'.")
@@ -55,6 +78,7 @@ var listCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(listCmd)
-	listCmd.Flags().StringArray("filter", []string{}, "a comma delimited list to filter output based on tags")
+	listCmd.Flags().StringArray("filter", []string{}, "filter expression the accounts must match, can be repeated")
+	listCmd.Flags().Bool("favorites", false, "only list accounts marked as favorite")
 	listCmd.Flags().StringP("output", "o", "text", "output results in specific format (text|json)")
 }
@@ -0,0 +1,136 @@
+package letme
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+	"gopkg.in/ini.v1"
+)
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove every letme managed profile and its cached credentials.",
+	Long: `Remove every letme managed profile from your '$HOME/.aws/credentials'
+and '$HOME/.aws/config' files, along with the cached credentials stored
+in '$HOME/.letme/.letme-db'. Profiles not written by letme are left untouched.
+The backups of your AWS files on '$HOME/.letme/backups' hold the credentials as well,
+so they are removed too and the logout can not be reverted with 'letme undo'.
+This will not remove anything on the DynamoDB side.
+	`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// get flags
+		contextFlag, _ := cmd.Flags().GetString("context")
+		expiredOnly, _ := cmd.Flags().GetBool("expired-only")
+
+		idents := utils.ReadDatabaseFile()
+		cachedAccounts := make(map[string]utils.Dataset)
+		for _, ident := range idents {
+			cachedAccounts[ident.Account.Name] = ident.Account
+		}
+
+		// gather letme managed profiles and cached accounts which may no longer have a profile
+		candidates := utils.GetLetmeManagedProfiles()
+		for _, ident := range idents {
+			if !slices.Contains(candidates, ident.Account.Name) {
+				candidates = append(candidates, ident.Account.Name)
+			}
+		}
+
+		// a missing aws file is treated as an empty one
+		credentialsFile, configFile := ini.Empty(), ini.Empty()
+		if _, err := os.Stat(utils.GetHomeDirectory() + "/.aws/credentials"); err == nil {
+			credentialsFile = utils.AwsCredsFileReadV2()
+		}
+		if _, err := os.Stat(utils.GetHomeDirectory() + "/.aws/config"); err == nil {
+			configFile = utils.AwsConfigFileReadV2()
+		}
+
+		now := time.Now().Unix()
+		removed := make(map[string]bool)
+		var credentialsChanged, configChanged bool
+		for _, profile := range candidates {
+			cached, isCached := cachedAccounts[profile]
+			configSection, errConfig := configFile.GetSection("profile " + profile)
+			credentialsSection, errCredentials := credentialsFile.GetSection(profile)
+
+			// profiles without cached credentials, such as region, native or hop profiles, record their context on
+			// the letme managed marker
+			profileContext := cached.Context
+			if !isCached {
+				if errCredentials == nil && utils.IsLetmeManaged(credentialsSection) {
+					profileContext, _ = utils.LetmeManagedMetadata(credentialsSection)
+				} else if errConfig == nil && utils.IsLetmeManaged(configSection) {
+					profileContext, _ = utils.LetmeManagedMetadata(configSection)
+				}
+			}
+
+			// skip profiles from other contexts or whose credentials are still valid, or may be since their expiry
+			// is unknown
+			if len(contextFlag) > 0 && profileContext != contextFlag {
+				continue
+			}
+			if expiredOnly && (!isCached || cached.Expiry > now) {
+				continue
+			}
+
+			if errCredentials == nil && utils.IsLetmeManaged(credentialsSection) {
+				credentialsFile.DeleteSection(profile)
+				credentialsChanged = true
+				fmt.Println("letme: removed profile '" + profile + "' entry from credentials file.")
+			}
+			if errConfig == nil && utils.IsLetmeManaged(configSection) {
+				configFile.DeleteSection("profile " + profile)
+				configChanged = true
+				fmt.Println("letme: removed profile '" + profile + "' entry from config file.")
+			}
+			if isCached {
+				fmt.Println("letme: removed cached credentials for '" + profile + "'.")
+			}
+			removed[profile] = true
+		}
+
+		if len(removed) == 0 {
+			fmt.Println("letme: nothing to remove.")
+			os.Exit(0)
+		}
+
+		if credentialsChanged {
+			err := utils.SaveAwsFile(credentialsFile, utils.GetHomeDirectory()+"/.aws/credentials")
+			utils.CheckAndReturnError(err)
+		}
+		if configChanged {
+			err := utils.SaveAwsFile(configFile, utils.GetHomeDirectory()+"/.aws/config")
+			utils.CheckAndReturnError(err)
+		}
+
+		var remaining []utils.Account
+		for _, ident := range idents {
+			if !removed[ident.Account.Name] {
+				remaining = append(remaining, ident)
+			}
+		}
+		if len(remaining) != len(idents) {
+			utils.WriteDatabaseFile(remaining)
+		}
+
+		if utils.DryRun {
+			fmt.Printf("letme: dry run, would log out from %v account(s) and remove %v backup(s) of your AWS files.\n", len(removed), len(utils.ListAwsFileBackups()))
+			os.Exit(0)
+		}
+		cleared, err := utils.ClearAwsFileBackups()
+		utils.CheckAndReturnError(err)
+		fmt.Printf("letme: logged out from %v account(s) and removed %v backup(s) of your AWS files.\n", len(removed), cleared)
+	},
+}
+
+func init() {
+	var expiredOnly bool
+	RootCmd.AddCommand(logoutCmd)
+	logoutCmd.Flags().String("context", "", "only remove profiles obtained with the specified context")
+	logoutCmd.Flags().BoolVarP(&expiredOnly, "expired-only", "", false, "only remove profiles whose cached credentials have expired")
+}
@@ -19,14 +19,30 @@ var obtainCmd = &cobra.Command{
 	Short: "Obtain account credentials.",
 	Long: `Obtain AWS STS assumed credentials once the user authenticates itself.
 Credentials will last 3600 seconds by default and can be used with the argument '--profile $ACCOUNT_NAME'
-within the AWS cli binary.`,
-	Args: cobra.MinimumNArgs(1),
+within the AWS cli binary.
+If no account is specified, an interactive picker lists the available accounts. Use '--tag' with
+a filter expression, as in 'letme list --filter', to narrow them down; when a single account
+matches it is obtained without the picker.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeAccountNames,
 	Run: func(cmd *cobra.Command, args []string) {
 		// get flags
 		inlineTokenMfa, _ := cmd.Flags().GetString("inline-mfa")
 		renew, _ := cmd.Flags().GetBool("renew")
 		credentialProcess, _ := cmd.Flags().GetBool("credential-process")
 		localCredentialProcessFlagV1, _ := cmd.Flags().GetBool("v1")
+		verify, _ := cmd.Flags().GetBool("verify")
+		policyFile, _ := cmd.Flags().GetString("policy-file")
+		policyArns, _ := cmd.Flags().GetStringArray("policy-arn")
+		roleName, _ := cmd.Flags().GetString("role")
+		region, _ := cmd.Flags().GetString("region")
+		allRegions, _ := cmd.Flags().GetBool("all-regions")
+		force, _ := cmd.Flags().GetBool("force")
+		contextFlag, _ := cmd.Flags().GetString("context")
+		filterTags, _ := cmd.Flags().GetStringArray("tag")
+		if len(contextFlag) > 0 {
+			utils.UseContext(contextFlag)
+		}
 
 		// get the current context
 		currentContext := utils.GetCurrentContext()
@@ -37,23 +53,79 @@ within the AWS cli binary.`,
 
 		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
 		utils.CheckAndReturnError(err)
+		if len(args) > 0 && len(filterTags) > 0 {
+			fmt.Println("letme: '--tag' cannot be used along with an account name.")
+			os.Exit(1)
+		}
+		if len(filterTags) > 0 {
+			if matches := utils.GetTableData(letmeContext.AwsDynamoDbTable, filterTags, cfg, "name"); len(matches) == 1 {
+				args = []string{matches[0].Name}
+				if !localCredentialProcessFlagV1 {
+					fmt.Println("letme: account '" + args[0] + "' is the only one matching your filters.")
+				}
+			}
+		}
+		if len(args) == 0 {
+			args = []string{pickAccount(letmeContext, filterTags, cfg)}
+		}
 		account := utils.GetAccount(letmeContext.AwsDynamoDbTable, cfg, args[0])
 
-		switch {
-		case len(account.Name) == 0:
+		if len(account.Name) == 0 {
 			fmt.Println("letme: the specified account does not exist in your DynamoDB.")
 			fmt.Println("letme: run 'letme list' to list available accounts.")
 			os.Exit(1)
-		case len(account.Region) == 0:
-			fmt.Println("letme: default region not set. Setting 'us-east-1' by default.")
-			account.Region[0] = "us-east-1"
-		case len(account.Role) == 0:
+		}
+		utils.SelectAccountRole(account, roleName, letmeContext.AwsDefaultRole)
+
+		if len(account.Role) == 0 {
 			fmt.Println("letme: the specified account does not have any role configured. Nothing to assume.")
 			os.Exit(1)
 		}
+		if len(account.Region) == 0 && !localCredentialProcessFlagV1 {
+			fmt.Println("letme: default region not set. Setting 'us-east-1' by default.")
+		}
+		if utils.DryRun && localCredentialProcessFlagV1 {
+			fmt.Println("letme: '--dry-run' cannot be used along with '--v1'.")
+			os.Exit(1)
+		}
+		if allRegions && credentialProcess {
+			fmt.Println("letme: '--all-regions' cannot be used along with '--credential-process'.")
+			os.Exit(1)
+		}
+		utils.SelectAccountRegion(account, region)
+		profileName := utils.GetProfileName(account)
+
+		// never overwrite profiles created by hand unless the user asks for it
+		if !localCredentialProcessFlagV1 && !force {
+			profileNames := []string{profileName}
+			if allRegions {
+				for _, accountRegion := range account.Region {
+					regionAccount := *account
+					regionAccount.SelectedRegion = accountRegion
+					profileNames = append(profileNames, utils.GetProfileName(&regionAccount))
+				}
+			}
+			for _, name := range profileNames {
+				if utils.IsUnmanagedProfile(name) {
+					fmt.Println("letme: profile '" + name + "' is not managed by letme, append argument '--force' to overwrite it.")
+					os.Exit(1)
+				}
+			}
+		}
+
+		// session policies passed as flags take precedence over the ones in the account item
+		if len(policyFile) > 0 || len(policyArns) > 0 {
+			account.SessionPolicy, account.SessionPolicyArns = "", policyArns
+			if len(policyFile) > 0 {
+				account.SessionPolicy = utils.ReadSessionPolicyFile(policyFile)
+			}
+		}
+		if (len(account.SessionPolicy) > 0 || len(account.SessionPolicyArns) > 0) && !localCredentialProcessFlagV1 {
+			fmt.Println("letme: credentials will be scoped down with a session policy.")
+		}
 
 		if credentialProcess {
-			utils.AwsConfigFileCredentialsProcessV1(args[0], account.Region[0])
+			utils.AwsConfigFileCredentialsProcessV1(account, utils.AccountRegion(account))
 		}
 
 		// overwrite the session name variable if the user provides it
@@ -85,9 +157,25 @@ within the AWS cli binary.`,
 			profileCredential, profileConfig = utils.AssumeRole(letmeContext, cfg, inlineTokenMfa, account, renew, localCredentialProcessFlagV1, authMethod)
 		}
 
-		utils.LoadAwsCredentials(account.Name, profileCredential)
-		utils.LoadAwsConfig(account.Name, profileConfig)
-		fmt.Println("letme: use the argument '--profile " + account.Name + "' to interact with the account.")
+		utils.LoadAwsCredentials(profileName, profileCredential)
+		utils.LoadAwsConfig(profileName, profileConfig)
+		utils.RecordAccountUsage(account.Name)
+
+		// write one profile per region sharing the same credentials
+		if allRegions {
+			for _, accountRegion := range account.Region {
+				account.SelectedRegion = accountRegion
+				regionProfileName := utils.GetProfileName(account)
+				utils.LoadAwsCredentials(regionProfileName, profileCredential)
+				utils.LoadAwsConfig(regionProfileName, utils.ProfileConfig{Output: profileConfig.Output, Region: accountRegion})
+				fmt.Println("letme: use the argument '--profile " + regionProfileName + "' to interact with the account in " + accountRegion + ".")
+			}
+		}
+		if verify && !utils.DryRun {
+			fmt.Println("letme: verifying credentials for '" + profileName + "':")
+			utils.PrintCallerIdentity(utils.AwsConfigFromProfileCredential(profileCredential, profileConfig.Region), profileName)
+		}
+		fmt.Println("letme: use the argument '--profile " + profileName + "' to interact with the account.")
 	},
 }
 
@@ -95,10 +183,20 @@ func init() {
 	var credentialProcess bool
 	var v1 bool
 	var renew bool
+	var verify bool
 	RootCmd.AddCommand(obtainCmd)
 	obtainCmd.Flags().String("inline-mfa", "", "pass the mfa token without user prompt")
 	obtainCmd.Flags().BoolVarP(&renew, "renew", "", false, "force new credentials to be assumed")
 	obtainCmd.Flags().BoolVarP(&credentialProcess, "credential-process", "", false, "obtain credentials using the credential_process entry in your aws config file.")
 	obtainCmd.Flags().BoolVarP(&v1, "v1", "", false, "output credentials following the credential_process version 1 standard.")
+	obtainCmd.Flags().String("context", "", "use the specified context instead of the active one")
+	obtainCmd.Flags().String("role", "", "named role of the account to assume, the profile is suffixed with the role name")
+	obtainCmd.Flags().String("region", "", "region of the profile, must be one of the account regions listed in DynamoDB")
+	obtainCmd.Flags().Bool("all-regions", false, "also write a profile suffixed with the region name for every account region")
+	obtainCmd.Flags().String("policy-file", "", "path to a json policy document used as inline session policy")
+	obtainCmd.Flags().StringArray("policy-arn", []string{}, "arn of a managed policy used as session policy, can be repeated")
+	obtainCmd.Flags().BoolVarP(&verify, "verify", "", false, "call sts GetCallerIdentity with the obtained credentials")
+	obtainCmd.Flags().StringArray("tag", []string{}, "filter expression to pick the account from, can be repeated")
+	obtainCmd.Flags().Bool("force", false, "overwrite profiles on your aws files which are not managed by letme")
 
 }
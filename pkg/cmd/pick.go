@@ -0,0 +1,74 @@
+package letme
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var pickCmd = &cobra.Command{
+	Use: "pick",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.ConfigFileHealth()
+	},
+	Short: "Interactively pick an account.",
+	Long: `Open a fuzzy finder over the accounts of the current context, recently used
+accounts are listed first. The selected account name is written to stdout,
+e.g. 'letme obtain $(letme pick)'. Running 'letme obtain' without arguments
+opens the same picker.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		// get the current context
+		currentContext := utils.GetCurrentContext()
+		letmeContext := utils.GetContextData(currentContext)
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+		utils.CheckAndReturnError(err)
+		fmt.Println(pickAccount(letmeContext, nil, cfg))
+	},
+}
+
+// Open the account picker with the accounts that match the filter tags, or the context tags when there are none.
+// Exits if the user cancels.
+func pickAccount(letmeContext *utils.LetmeContext, filterTags []string, cfg aws.Config) string {
+	if !utils.IsTerminal() {
+		fmt.Fprintln(os.Stderr, "letme: no account specified and no interactive terminal available to pick one.")
+		os.Exit(1)
+	}
+
+	tags := letmeContext.Tags
+	if len(filterTags) > 0 {
+		tags = filterTags
+	}
+	tableData := utils.GetTableData(letmeContext.AwsDynamoDbTable, tags, cfg, utils.ListAttributes...)
+	if len(tableData) == 0 {
+		fmt.Fprintln(os.Stderr, "letme: no items found that matched your filters on DynamoDB Table '"+letmeContext.AwsDynamoDbTable+"'.")
+		os.Exit(1)
+	}
+
+	// the completion cache holds the accounts of the context tags, a filtered subset would hide the others
+	if len(filterTags) == 0 {
+		utils.UpdateCompletionCache(utils.GetCurrentContext(), tableData)
+	}
+
+	// messages go to stderr, stdout only holds the picked account so it can be used as 'letme obtain $(letme pick)'
+	accountName, err := utils.PickAccount(tableData)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if len(accountName) == 0 {
+		fmt.Fprintln(os.Stderr, "letme: no account selected.")
+		os.Exit(1)
+	}
+	return accountName
+}
+
+func init() {
+	RootCmd.AddCommand(pickCmd)
+}
@@ -0,0 +1,72 @@
+package letme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var recentCmd = &cobra.Command{
+	Use: "recent",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.ConfigFileHealth()
+	},
+	Short: "List recently obtained accounts.",
+	Long: `List the accounts obtained with the current context, most recently used first,
+along with how many times they were obtained. Favorite accounts are marked with '*'.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, err := cmd.Flags().GetInt("limit")
+		utils.CheckAndReturnError(err)
+		output, err := cmd.Flags().GetString("output")
+		utils.CheckAndReturnError(err)
+
+		// get the current context
+		currentContext := utils.GetCurrentContext()
+		var usage []utils.AccountUsage
+		for _, entry := range utils.GetAccountUsage(currentContext) {
+			if entry.Count > 0 {
+				usage = append(usage, entry)
+			}
+		}
+		if limit > 0 && len(usage) > limit {
+			usage = usage[:limit]
+		}
+
+		if len(usage) == 0 {
+			fmt.Println("letme: no accounts obtained yet using '" + currentContext + "' context.")
+			os.Exit(1)
+		}
+
+		switch output {
+		case "text":
+			fmt.Println("Recent accounts using '" + currentContext + "' context:\n")
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "  NAME:\tTIMES OBTAINED:\tLAST USED:")
+			fmt.Fprintln(w, "  -----\t---------------\t----------")
+			for _, entry := range usage {
+				favorite := " "
+				if entry.Favorite {
+					favorite = "*"
+				}
+				fmt.Fprintf(w, "%s %s\t%v\t%s\n", favorite, entry.Name, entry.Count, time.Unix(entry.LastUsed, 0).Format(time.RFC1123))
+			}
+			w.Flush()
+		case "json":
+			jsonData, err := json.MarshalIndent(usage, "", " ")
+			utils.CheckAndReturnError(err)
+			fmt.Println(string(jsonData))
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(recentCmd)
+	recentCmd.Flags().IntP("limit", "n", 10, "maximum number of accounts to list, 0 lists all of them")
+	recentCmd.Flags().StringP("output", "o", "text", "output results in specific format (text|json)")
+}
@@ -13,12 +13,31 @@ var removeCmd = &cobra.Command{
 	Use:   "remove",
 	Short: "Remove the account entry in your AWS files.",
 	Long: `Remove the account entry in your AWS files.
+The profile name is resolved through the profile template of the context,
+falling back to the given name when no such profile exists.
 This will not remove anything on the DynamoDB side. Use it for
 cleanup purposes and sanitizing your '$HOME/.aws/credentials'
 and '$HOME/.aws/config' files.
 	`,
-	Args: cobra.MinimumNArgs(1),
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeLocalProfiles,
 	Run: func(cmd *cobra.Command, args []string) {
+		roleName, _ := cmd.Flags().GetString("role")
+		region, _ := cmd.Flags().GetString("region")
+		contextFlag, _ := cmd.Flags().GetString("context")
+		if len(contextFlag) > 0 {
+			utils.UseContext(contextFlag)
+		}
+
+		// resolve the profile name through the context profile template, fall back to the given profile name
+		profileName := args[0]
+		account := &utils.DynamoDbAccountConfig{Name: args[0], SelectedRole: roleName, SelectedRegion: region}
+		if templateName := utils.GetProfileName(account); templateName != profileName {
+			accountInFile := utils.CheckAccountLocally(templateName)
+			if accountInFile["credentials"] || accountInFile["config"] {
+				profileName = templateName
+			}
+		}
 
 		// read both awscredentials and config files
 		credentials := utils.AwsCredsFileReadV2()
@@ -29,38 +48,38 @@ and '$HOME/.aws/config' files.
 		_, errConf := os.OpenFile(utils.GetHomeDirectory()+"/.aws/config", os.O_RDWR|os.O_APPEND, 0600)
 
 		if !(errors.Is(errCred, os.ErrNotExist)) && !(errors.Is(errConf, os.ErrNotExist)) {
-			accountInFile := utils.CheckAccountLocally(args[0])
+			accountInFile := utils.CheckAccountLocally(profileName)
 			switch {
 			case accountInFile["credentials"]:
-				credentialSection, err := credentials.GetSection(args[0])
+				credentialSection, err := credentials.GetSection(profileName)
 				utils.CheckAndReturnError(err)
-				if credentialSection.Comment != "; letme managed" {
-					err := fmt.Errorf("letme: account " + args[0] + " is not managed by letme, cannot be deleted.")
+				if !utils.IsLetmeManaged(credentialSection) {
+					err := fmt.Errorf("letme: account " + profileName + " is not managed by letme, cannot be deleted.")
 					utils.CheckAndReturnError(err)
 				}
-				credentials.DeleteSection(args[0])
-				if err := credentials.SaveTo(utils.GetHomeDirectory() + "/.aws/credentials"); err != nil {
+				credentials.DeleteSection(profileName)
+				if err := utils.SaveAwsFile(credentials, utils.GetHomeDirectory()+"/.aws/credentials"); err != nil {
 					utils.CheckAndReturnError(err)
 				}
-				fmt.Println("letme: removed profile '" + args[0] + "' entry from credentials file.")
+				fmt.Println("letme: removed profile '" + profileName + "' entry from credentials file.")
 				fallthrough
 			case accountInFile["config"]:
-				configSection, err := config.GetSection("profile " + args[0])
+				configSection, err := config.GetSection("profile " + profileName)
 				utils.CheckAndReturnError(err)
-				if configSection.Comment != "; letme managed" {
-					err := fmt.Errorf("letme: account " + args[0] + " is not managed by letme, cannot be deleted.")
+				if !utils.IsLetmeManaged(configSection) {
+					err := fmt.Errorf("letme: account " + profileName + " is not managed by letme, cannot be deleted.")
 					utils.CheckAndReturnError(err)
 				}
-				config.DeleteSection("profile " + args[0])
-				if err := config.SaveTo(utils.GetHomeDirectory() + "/.aws/config"); err != nil {
+				config.DeleteSection("profile " + profileName)
+				if err := utils.SaveAwsFile(config, utils.GetHomeDirectory()+"/.aws/config"); err != nil {
 					utils.CheckAndReturnError(err)
 				}
-				fmt.Println("letme: removed profile '" + args[0] + "' entry from config file.")
+				fmt.Println("letme: removed profile '" + profileName + "' entry from config file.")
 			default:
-				fmt.Println("letme: unable to remove profile '" + args[0] + "', not found on your local aws files.")
+				fmt.Println("letme: unable to remove profile '" + profileName + "', not found on your local aws files.")
 				os.Exit(1)
 			}
-			utils.RemoveAccountFromDatabaseFile(args[0])
+			utils.RemoveAccountFromDatabaseFile(profileName)
 
 		}
 	},
@@ -68,4 +87,7 @@ and '$HOME/.aws/config' files.
 
 func init() {
 	RootCmd.AddCommand(removeCmd)
+	removeCmd.Flags().String("context", "", "use the profile template of the specified context instead of the active one")
+	removeCmd.Flags().String("role", "", "named role of the account, used to resolve the profile name")
+	removeCmd.Flags().String("region", "", "region of the account, used to resolve the profile name")
 }
@@ -0,0 +1,142 @@
+package letme
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+	"gopkg.in/ini.v1"
+)
+
+var setupProfilesCmd = &cobra.Command{
+	Use: "setup-profiles",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.ConfigFileHealth()
+	},
+	Short: "Write aws cli profiles for every account.",
+	Long: `Write a profile in your AWS config file for every account of the current context.
+With '--native', profiles use 'role_arn' and 'source_profile' so the AWS cli and SDKs
+assume the role chain themselves without letme. Every intermediate hop of a chained
+role is written as its own profile suffixed with '-hop-N'.
+With '--credential-process', profiles call 'letme obtain $ACCOUNT_NAME --v1' whenever
+credentials are needed.
+Accounts with named roles get one profile per role. Use '--prune' to remove the letme
+credential_process profiles of the current context whose account left the DynamoDB table.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		native, _ := cmd.Flags().GetBool("native")
+		credentialProcess, _ := cmd.Flags().GetBool("credential-process")
+		prune, _ := cmd.Flags().GetBool("prune")
+		filterTags, _ := cmd.Flags().GetStringArray("tag")
+		if native == credentialProcess {
+			fmt.Println("letme: choose the kind of profiles to write, either '--native' or '--credential-process'.")
+			os.Exit(1)
+		}
+
+		// get the current context
+		currentContext := utils.GetCurrentContext()
+		letmeContext := utils.GetContextData(currentContext)
+		if letmeContext.AwsSessionDuration == 0 {
+			letmeContext.AwsSessionDuration = 3600
+		}
+		if len(filterTags) != 0 {
+			letmeContext.Tags = filterTags
+		}
+
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+		utils.CheckAndReturnError(err)
+		tableData := utils.GetTableData(letmeContext.AwsDynamoDbTable, letmeContext.Tags, cfg)
+		if len(tableData) == 0 {
+			fmt.Println("letme: no items found that matched your filters on DynamoDB Table '" + letmeContext.AwsDynamoDbTable + "'.")
+			os.Exit(1)
+		}
+
+		configFile := utils.AwsConfigFileReadV2()
+		var credentialsFile *ini.File
+		if _, err := os.Stat(utils.GetHomeDirectory() + "/.aws/credentials"); err == nil {
+			credentialsFile = utils.AwsCredsFileReadV2()
+		}
+		var written []string
+		for _, item := range tableData {
+			// one profile per named role, plus the plain role chain if the account has one
+			roleNames := utils.GetAccountRoleNames(&item)
+			if len(item.Role) > 0 || len(roleNames) == 0 {
+				roleNames = append([]string{""}, roleNames...)
+			}
+			for _, roleName := range roleNames {
+				account := item
+				if len(roleName) > 0 {
+					account.Role, account.SelectedRole = item.Roles[roleName], roleName
+				}
+				if len(account.Role) == 0 {
+					fmt.Println("letme: account '" + account.Name + "' does not have any role configured, skipping it.")
+					continue
+				}
+				utils.SelectAccountRegion(&account, "")
+				if native {
+					written = append(written, utils.AwsConfigFileNativeProfiles(configFile, letmeContext, &account)...)
+					continue
+				}
+
+				// static credentials take precedence over credential_process, so letme ones are removed
+				profileName := utils.GetProfileName(&account)
+				if credentialsFile != nil {
+					if section, err := credentialsFile.GetSection(profileName); err == nil {
+						if !utils.IsLetmeManaged(section) {
+							fmt.Println("letme: profile '" + profileName + "' is not managed by letme, skipping account '" + account.Name + "'.")
+							continue
+						}
+						credentialsFile.DeleteSection(profileName)
+					}
+				}
+				if _, ok := utils.AwsConfigFileCredentialProcessProfile(configFile, &account); !ok {
+					fmt.Println("letme: profile '" + profileName + "' is not managed by letme, skipping account '" + account.Name + "'.")
+					continue
+				}
+				written = append(written, profileName)
+			}
+		}
+
+		// remove the credential_process profiles of accounts which are no longer in the table
+		if prune {
+			catalog, err := utils.ScanAccountNames(letmeContext.AwsDynamoDbTable, []string{}, cfg, letmeContext.AwsDynamoDbScanSegments)
+			utils.CheckAndReturnError(err)
+			accountNames := make(map[string]bool)
+			for _, account := range catalog {
+				accountNames[account.Name] = true
+			}
+			for _, section := range configFile.Sections() {
+				accountName, accountContext, ok := utils.CredentialProcessAccount(section)
+				if !ok || !utils.IsLetmeManaged(section) || accountContext != currentContext || accountNames[accountName] {
+					continue
+				}
+				profileName := strings.TrimPrefix(section.Name(), "profile ")
+				configFile.DeleteSection(section.Name())
+				if credentialsFile != nil {
+					if section, err := credentialsFile.GetSection(profileName); err == nil && utils.IsLetmeManaged(section) {
+						credentialsFile.DeleteSection(profileName)
+					}
+				}
+				fmt.Println("letme: removed profile '" + profileName + "', account '" + accountName + "' is no longer in DynamoDB.")
+			}
+		}
+
+		utils.CheckAndReturnError(utils.SaveAwsFile(configFile, utils.GetHomeDirectory()+"/.aws/config"))
+		if credentialsFile != nil {
+			utils.CheckAndReturnError(utils.SaveAwsFile(credentialsFile, utils.GetHomeDirectory()+"/.aws/credentials"))
+		}
+		fmt.Printf("letme: wrote %v profiles to your aws config file using '%s' context.\n", len(written), currentContext)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(setupProfilesCmd)
+	setupProfilesCmd.Flags().Bool("native", false, "write profiles using 'role_arn' and 'source_profile' instead of letme credentials")
+	setupProfilesCmd.Flags().Bool("credential-process", false, "write profiles which obtain credentials through letme using credential_process")
+	setupProfilesCmd.Flags().Bool("prune", false, "remove credential_process profiles of accounts which are no longer in DynamoDB")
+	setupProfilesCmd.Flags().StringArray("tag", []string{}, "only write profiles for accounts matching this filter expression, can be repeated")
+}
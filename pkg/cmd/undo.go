@@ -0,0 +1,36 @@
+package letme
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last change letme made to your AWS files.",
+	Long: `Restore your '$HOME/.aws/credentials' and '$HOME/.aws/config' files as they were
+before the last letme command which changed them. Running it again keeps going back
+through the backups listed by 'letme backups list'.`,
+	Args: cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		utils.RejectDryRun(cmd.CommandPath())
+		backups := utils.ListAwsFileBackups()
+		if len(backups) == 0 {
+			fmt.Println("letme: no backups found, nothing to undo.")
+			os.Exit(1)
+		}
+
+		backup := backups[0]
+		utils.CheckAndReturnError(utils.RestoreAwsFileBackup(backup, false))
+		utils.CheckAndReturnError(utils.DeleteAwsFileBackup(backup.Id))
+		fmt.Println("letme: reverted '" + backup.Command + "', restored " + strings.Join(backup.Files, " and ") + " files from " + backup.Created.Local().Format("2006-01-02 15:04:05") + ".")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(undoCmd)
+}
@@ -0,0 +1,65 @@
+package letme
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	utils "github.com/lockedinspace/letme/pkg"
+	"github.com/spf13/cobra"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use: "whoami [account]",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.ConfigFileHealth()
+	},
+	Short: "Show the identity behind an account's credentials.",
+	Long: `Call sts GetCallerIdentity using the cached credentials of an obtained account,
+or its profile in your AWS files, and show the assumed role arn, account id,
+session name and remaining lifetime of the credentials.
+Without an account, the source profile of the current context is used.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeAccountNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		// get the current context
+		currentContext := utils.GetCurrentContext()
+		letmeContext := utils.GetContextData(currentContext)
+
+		if len(args) == 0 {
+			cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(letmeContext.AwsSourceProfile), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+			utils.CheckAndReturnError(err)
+			utils.PrintCallerIdentity(cfg, "")
+			os.Exit(0)
+		}
+
+		// prefer the cached credentials, fall back to the profile in the aws files
+		if utils.CheckAccountAvailability(args[0]) {
+			cachedCredentials := utils.ReturnAccountCredentials(args[0])
+			if len(cachedCredentials["AccessKeyId"]) > 0 {
+				profileCredential := utils.ProfileCredential{
+					AccessKey:    cachedCredentials["AccessKeyId"],
+					SecretKey:    cachedCredentials["SecretAccessKey"],
+					SessionToken: cachedCredentials["SessionToken"],
+				}
+				utils.PrintCallerIdentity(utils.AwsConfigFromProfileCredential(profileCredential, letmeContext.AwsSourceProfileRegion), args[0])
+				os.Exit(0)
+			}
+		}
+
+		accountInFile := utils.CheckAccountLocally(args[0])
+		if !accountInFile["credentials"] && !accountInFile["config"] {
+			fmt.Println("letme: profile '" + args[0] + "' not found on your local aws files.")
+			fmt.Println("letme: run 'letme obtain " + args[0] + "' to obtain credentials.")
+			os.Exit(1)
+		}
+		cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithSharedConfigProfile(args[0]), config.WithRegion(letmeContext.AwsSourceProfileRegion))
+		utils.CheckAndReturnError(err)
+		utils.PrintCallerIdentity(cfg, args[0])
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(whoamiCmd)
+}
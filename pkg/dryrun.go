@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// Set by '--dry-run', letme shows the sts calls and the changes it would make to the local files without making them
+var DryRun bool
+
+// Exit if '--dry-run' was given to a command which can not show its changes without making them
+func RejectDryRun(command string) {
+	if DryRun {
+		CheckAndReturnError(fmt.Errorf("letme: '--dry-run' is not supported by '%s'", command))
+	}
+}
+
+// Number of unchanged lines shown around every change of a diff
+const diffContextLines = 3
+
+// Print the AssumeRole call letme would make and return placeholder credentials in its place
+func dryRunAssumeRole(input *sts.AssumeRoleInput) *sts.AssumeRoleOutput {
+	fmt.Printf("letme: dry run, would call sts AssumeRole on '%s' with session name '%s' for %vs", *input.RoleArn, aws.ToString(input.RoleSessionName), aws.ToInt32(input.DurationSeconds))
+	var details []string
+	if input.SerialNumber != nil {
+		details = append(details, "mfa '"+*input.SerialNumber+"'")
+	}
+	if input.ExternalId != nil {
+		details = append(details, "external id '"+*input.ExternalId+"'")
+	}
+	if input.SourceIdentity != nil {
+		details = append(details, "source identity '"+*input.SourceIdentity+"'")
+	}
+	if len(input.Tags) > 0 {
+		details = append(details, fmt.Sprintf("%v session tags", len(input.Tags)))
+	}
+	if input.Policy != nil || len(input.PolicyArns) > 0 {
+		details = append(details, "session policy")
+	}
+	if len(details) > 0 {
+		fmt.Print(" (" + strings.Join(details, ", ") + ")")
+	}
+	fmt.Println(".")
+
+	return &sts.AssumeRoleOutput{
+		Credentials: &stsTypes.Credentials{
+			AccessKeyId:     aws.String("DRYRUNACCESSKEYID"),
+			SecretAccessKey: aws.String("dry-run-secret-access-key"),
+			SessionToken:    aws.String("dry-run-session-token"),
+			Expiration:      aws.Time(time.Now().Add(time.Duration(aws.ToInt32(input.DurationSeconds)) * time.Second)),
+		},
+	}
+}
+
+// Print a unified diff between two versions of a file, nothing is printed if they are equal
+func PrintUnifiedDiff(path string, before string, after string) {
+	if before == after {
+		return
+	}
+	fmt.Println("--- " + path)
+	fmt.Println("+++ " + path + " (dry run)")
+	fmt.Print(UnifiedDiff(before, after))
+}
+
+// Return the hunks of a unified diff between two versions of a text
+func UnifiedDiff(before string, after string) string {
+	return unifiedDiffHunks(splitLines(before), splitLines(after))
+}
+
+// Split text in lines, without the trailing line breaks
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Edit of a diff, along with the line numbers it applies to on both sides
+type diffEdit struct {
+	op   byte
+	line string
+	i, j int
+}
+
+// Return the edit script between two lists of lines. Lines common to the start and the end are skipped and the rest
+// is compared with the Myers algorithm, which takes O((N+M)D) time and O(D^2) memory for D changed lines. Blocks with
+// more than maxDiffEdits changed lines are shown as removed and added whole. Removals are listed before additions.
+func diffEdits(a []string, b []string) []diffEdit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := []byte(strings.Repeat(" ", prefix))
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	ops = append(ops, strings.Repeat(" ", suffix)...)
+
+	// move the removals of every run of changes before its additions
+	for start := 0; start < len(ops); start++ {
+		if ops[start] == ' ' {
+			continue
+		}
+		end := start
+		for end < len(ops) && ops[end] != ' ' {
+			end++
+		}
+		removed := strings.Count(string(ops[start:end]), "-")
+		copy(ops[start:], strings.Repeat("-", removed)+strings.Repeat("+", end-start-removed))
+		start = end
+	}
+
+	var edits []diffEdit
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op {
+		case ' ':
+			edits = append(edits, diffEdit{op, a[i], i, j})
+			i++
+			j++
+		case '-':
+			edits = append(edits, diffEdit{op, a[i], i, j})
+			i++
+		default:
+			edits = append(edits, diffEdit{op, b[j], i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+// Maximum number of changed lines the Myers algorithm looks for, bigger changes are shown as a whole block replaced
+const maxDiffEdits = 1000
+
+// Return the shortest edit script between two lists of lines as ' ', '-' and '+' operations
+func myersDiff(a []string, b []string) []byte {
+	n, m := len(a), len(b)
+	replaced := []byte(strings.Repeat("-", n) + strings.Repeat("+", m))
+	if n == 0 || m == 0 {
+		return replaced
+	}
+
+	// v[offset+k] holds the furthest line of a reached on diagonal k, trace keeps v as it was before every step
+	limit := min(n+m, maxDiffEdits)
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		// only diagonals -d-1 to d+1 are read on step d
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return myersBacktrack(trace, n, m)
+			}
+		}
+	}
+	return replaced
+}
+
+// Walk the steps of the Myers algorithm back from the end of both lists, returning the operations in order
+func myersBacktrack(trace [][]int, n int, m int) []byte {
+	var ops []byte
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		// trace[d] holds diagonals -d-1 to d+1 at indexes 0 to 2d+2
+		v := trace[d]
+		k := x - y
+		previousK := k - 1
+		if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+			previousK = k + 1
+		}
+		previousX := v[previousK+d+1]
+		previousY := previousX - previousK
+		for x > previousX && y > previousY {
+			ops = append(ops, ' ')
+			x--
+			y--
+		}
+		if x == previousX {
+			ops = append(ops, '+')
+			y--
+		} else {
+			ops = append(ops, '-')
+			x--
+		}
+	}
+	for ; x > 0; x-- {
+		ops = append(ops, ' ')
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// Render the hunks of a unified diff between two lists of lines
+func unifiedDiffHunks(a []string, b []string) string {
+	edits := diffEdits(a, b)
+
+	var hunks strings.Builder
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// grow the hunk while changes are close enough to share their context lines
+		first := max(start-diffContextLines, 0)
+		last := start
+		for k := start; k < len(edits); k++ {
+			if edits[k].op != ' ' {
+				last = k
+			} else if k-last > 2*diffContextLines {
+				break
+			}
+		}
+		end := min(last+diffContextLines+1, len(edits))
+
+		var removed, added int
+		for _, e := range edits[first:end] {
+			if e.op != '+' {
+				removed++
+			}
+			if e.op != '-' {
+				added++
+			}
+		}
+		fromLine, toLine := edits[first].i+1, edits[first].j+1
+		if removed == 0 {
+			fromLine--
+		}
+		if added == 0 {
+			toLine--
+		}
+		fmt.Fprintf(&hunks, "@@ -%v,%v +%v,%v @@\n", fromLine, removed, toLine, added)
+		for _, e := range edits[first:end] {
+			hunks.WriteString(string(e.op) + e.line + "\n")
+		}
+		start = end
+	}
+	return hunks.String()
+}
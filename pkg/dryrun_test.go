@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "[default]\nregion = us-east-2\noutput = json\n\n[profile a]\nregion = eu-west-1\n"
+	after := "[default]\nregion = us-east-1\noutput = json\n\n[profile a]\nregion = eu-west-1\n[profile b]\n"
+	expected := "@@ -1,6 +1,7 @@\n [default]\n-region = us-east-2\n+region = us-east-1\n output = json\n \n [profile a]\n region = eu-west-1\n+[profile b]\n"
+	if diff := UnifiedDiff(before, after); diff != expected {
+		t.Errorf("diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+	if diff := UnifiedDiff(before, before); len(diff) > 0 {
+		t.Errorf("diff of equal texts:\n%s", diff)
+	}
+	if diff := UnifiedDiff("", "a\n"); diff != "@@ -0,0 +1,1 @@\n+a\n" {
+		t.Errorf("diff from an empty text:\n%s", diff)
+	}
+}
+
+// Applying the edits to the first list must give back both lists, with removals before additions on every run of
+// changes
+func TestDiffEdits(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(30))
+		for i := range lines {
+			lines[i] = fmt.Sprint(random.Intn(5))
+		}
+		return lines
+	}
+	for n := 0; n < 500; n++ {
+		a, b := randomLines(), randomLines()
+		var fromA, fromB []string
+		var previous byte
+		for _, edit := range diffEdits(a, b) {
+			if edit.op != ' ' {
+				if edit.i != len(fromA) || edit.j != len(fromB) {
+					t.Fatalf("%v %v: edit %v is on the wrong lines", a, b, edit)
+				}
+			}
+			if edit.op == '-' && previous == '+' {
+				t.Fatalf("%v %v: removal after an addition", a, b)
+			}
+			if edit.op != '+' {
+				fromA = append(fromA, edit.line)
+			}
+			if edit.op != '-' {
+				fromB = append(fromB, edit.line)
+			}
+			previous = edit.op
+		}
+		if !slices.Equal(fromA, a) || !slices.Equal(fromB, b) {
+			t.Fatalf("%v %v: edits give %v %v", a, b, fromA, fromB)
+		}
+	}
+}
+
+// Big files are compared without allocating memory for every pair of lines
+func TestUnifiedDiffLargeFiles(t *testing.T) {
+	lines := make([]string, 20000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("key%v = value%v", i, i)
+	}
+	before := strings.Join(lines, "\n") + "\n"
+	lines[10000] = "key10000 = changed"
+	after := strings.Join(lines, "\n") + "\n"
+	if diff := UnifiedDiff(before, after); strings.Count(diff, "\n") != 9 {
+		t.Errorf("diff of a single changed line:\n%s", diff)
+	}
+
+	// changes beyond maxDiffEdits are shown as the whole block replaced
+	var replaced []string
+	for i := range lines {
+		replaced = append(replaced, fmt.Sprintf("other%v", i))
+	}
+	diff := UnifiedDiff(before, strings.Join(replaced, "\n")+"\n")
+	if strings.Count(diff, "\n-") != len(lines) || strings.Count(diff, "\n+") != len(lines) {
+		t.Errorf("diff of a replaced file has %v removals and %v additions", strings.Count(diff, "\n-"), strings.Count(diff, "\n+"))
+	}
+}
@@ -0,0 +1,387 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Account filter parsed from expressions such as 'env=prod AND (team=payments OR team=risk) AND NOT deprecated'.
+// Terms are tags, 'key=value' tags included, or 'name=' and 'region=' matches. Values can be exact, globs using '*'
+// and '?', or regular expressions between slashes such as 'region=/^eu-/'. Terms are combined with AND, OR, NOT and
+// parentheses, terms next to each other are joined with AND.
+type AccountFilter struct {
+	root *filterNode
+}
+
+// Node of a parsed filter expression, either an operator or a term
+type filterNode struct {
+	op       string
+	children []*filterNode
+	field    string
+	key      string
+	value    string
+	pattern  *regexp.Regexp
+}
+
+// Parse filter expressions, every expression must match. Expressions without terms match every account.
+func ParseAccountFilter(expressions []string) (*AccountFilter, error) {
+	root := &filterNode{op: "AND"}
+	for _, expression := range expressions {
+		tokens, err := tokenizeFilter(expression)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+		parser := filterParser{tokens: tokens}
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, fmt.Errorf("letme: invalid filter '%s': %v", expression, err)
+		}
+		if parser.position < len(tokens) {
+			return nil, fmt.Errorf("letme: invalid filter '%s': unexpected '%s'", expression, tokens[parser.position])
+		}
+		root.children = append(root.children, node)
+	}
+	return &AccountFilter{root: root}, nil
+}
+
+// Check if the filter has no terms
+func (filter *AccountFilter) Empty() bool {
+	return len(filter.root.children) == 0
+}
+
+// Check if an account matches the filter
+func (filter *AccountFilter) Match(account *DynamoDbAccountConfig) bool {
+	return filter.root.match(account)
+}
+
+// Return the tags every matching account must have, which can be looked up on the tag index
+func (filter *AccountFilter) RequiredTags() []string {
+	var tags []string
+	for _, node := range filter.root.conjuncts() {
+		if node.op == "TERM" && node.field == "tag" && node.pattern == nil {
+			tags = append(tags, node.key+node.value)
+		}
+	}
+	return tags
+}
+
+// Return the DynamoDB filter expression of the parts of the filter DynamoDB can evaluate, along with its attribute
+// names and values. Globs other than name prefixes and regular expressions are left to Match.
+func (filter *AccountFilter) DynamoDbExpression() (string, map[string]string, map[string]dynamodbTypes.AttributeValue) {
+	builder := newDynamoDbFilter(0)
+	var expressions []string
+	for _, node := range filter.root.conjuncts() {
+		if expression, ok := builder.translate(node); ok {
+			expressions = append(expressions, expression)
+		}
+	}
+	return strings.Join(expressions, " AND "), builder.names, builder.values
+}
+
+// Attribute names and values of a DynamoDB filter expression being built. Values are numbered with a counter so
+// placeholders stay unique when subtrees which can not be translated are dropped.
+type dynamoDbFilter struct {
+	names   map[string]string
+	values  map[string]dynamodbTypes.AttributeValue
+	counter int
+}
+
+func newDynamoDbFilter(counter int) *dynamoDbFilter {
+	return &dynamoDbFilter{names: make(map[string]string), values: make(map[string]dynamodbTypes.AttributeValue), counter: counter}
+}
+
+// Translate a subtree on its own names and values, which are only added to the filter if the whole subtree can be
+// evaluated by DynamoDB. DynamoDB rejects expressions with unused names or values.
+func (builder *dynamoDbFilter) translate(node *filterNode) (string, bool) {
+	subtree := newDynamoDbFilter(builder.counter)
+	expression, ok := node.dynamoDbExpression(subtree)
+	if !ok {
+		return "", false
+	}
+	for placeholder, name := range subtree.names {
+		builder.names[placeholder] = name
+	}
+	for placeholder, value := range subtree.values {
+		builder.values[placeholder] = value
+	}
+	builder.counter = subtree.counter
+	return expression, true
+}
+
+// Return the nodes joined by AND at the top of the filter
+func (node *filterNode) conjuncts() []*filterNode {
+	if node.op != "AND" {
+		return []*filterNode{node}
+	}
+	var nodes []*filterNode
+	for _, child := range node.children {
+		nodes = append(nodes, child.conjuncts()...)
+	}
+	return nodes
+}
+
+func (node *filterNode) match(account *DynamoDbAccountConfig) bool {
+	switch node.op {
+	case "AND":
+		for _, child := range node.children {
+			if !child.match(account) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		for _, child := range node.children {
+			if child.match(account) {
+				return true
+			}
+		}
+		return false
+	case "NOT":
+		return !node.children[0].match(account)
+	}
+
+	switch node.field {
+	case "name":
+		return node.matchValue(account.Name)
+	case "region":
+		return slices.ContainsFunc(account.Region, node.matchValue)
+	}
+	return slices.ContainsFunc(account.Tags, node.matchValue)
+}
+
+func (node *filterNode) matchValue(value string) bool {
+	value, found := strings.CutPrefix(value, node.key)
+	switch {
+	case !found:
+		return false
+	case node.pattern != nil:
+		return node.pattern.MatchString(value)
+	}
+	return value == node.value
+}
+
+func (node *filterNode) dynamoDbExpression(builder *dynamoDbFilter) (string, bool) {
+	switch node.op {
+	case "AND", "OR":
+		var expressions []string
+		for _, child := range node.children {
+			expression, ok := builder.translate(child)
+			if !ok {
+				return "", false
+			}
+			expressions = append(expressions, expression)
+		}
+		return "(" + strings.Join(expressions, " "+node.op+" ") + ")", true
+	case "NOT":
+		expression, ok := builder.translate(node.children[0])
+		if !ok {
+			return "", false
+		}
+		return "(NOT " + expression + ")", true
+	}
+
+	value := node.value
+	prefix := node.field == "name" && node.pattern != nil && strings.HasSuffix(value, "*") && !strings.ContainsAny(strings.TrimSuffix(value, "*"), "*?/")
+	if node.pattern != nil && !prefix {
+		return "", false
+	}
+	placeholder := fmt.Sprintf(":f%v", builder.counter)
+	builder.counter++
+	builder.values[placeholder] = &dynamodbTypes.AttributeValueMemberS{Value: node.key + strings.TrimSuffix(value, "*")}
+	switch node.field {
+	case "name":
+		builder.names["#fname"] = "name"
+		if prefix {
+			return "begins_with(#fname, " + placeholder + ")", true
+		}
+		return "#fname = " + placeholder, true
+	case "region":
+		builder.names["#fregion"] = "region"
+		return "contains(#fregion, " + placeholder + ")", true
+	}
+	builder.names["#ftags"] = "tags"
+	return "contains(#ftags, " + placeholder + ")", true
+}
+
+// Split a filter expression in parentheses and words. Words can be quoted, regular expressions between slashes
+// keep their spaces and parentheses.
+func tokenizeFilter(expression string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expression); {
+		switch character := expression[i]; {
+		case character == ' ' || character == '\t':
+			i++
+		case character == '(' || character == ')':
+			tokens = append(tokens, string(character))
+			i++
+		default:
+			var word strings.Builder
+			for i < len(expression) && !strings.ContainsRune(" \t()", rune(expression[i])) {
+				switch {
+				case expression[i] == '"':
+					end := strings.IndexByte(expression[i+1:], '"')
+					if end < 0 {
+						return nil, fmt.Errorf("letme: invalid filter '%s': unterminated quote", expression)
+					}
+					word.WriteString(expression[i+1 : i+1+end])
+					i += end + 2
+				case expression[i] == '/' && (word.Len() == 0 || strings.HasSuffix(word.String(), "=")):
+					end := i + 1
+					for end < len(expression) && expression[end] != '/' {
+						if expression[end] == '\\' {
+							end++
+						}
+						end++
+					}
+					if end >= len(expression) {
+						return nil, fmt.Errorf("letme: invalid filter '%s': unterminated regular expression", expression)
+					}
+					word.WriteString(expression[i : end+1])
+					i = end + 1
+				default:
+					word.WriteByte(expression[i])
+					i++
+				}
+			}
+			tokens = append(tokens, word.String())
+		}
+	}
+	return tokens, nil
+}
+
+// Recursive descent parser of filter expressions, NOT binds tighter than AND, which binds tighter than OR
+type filterParser struct {
+	tokens   []string
+	position int
+}
+
+func (parser *filterParser) peek() string {
+	if parser.position < len(parser.tokens) {
+		return parser.tokens[parser.position]
+	}
+	return ""
+}
+
+func (parser *filterParser) parseOr() (*filterNode, error) {
+	node, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek() == "OR" {
+		parser.position++
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if node.op != "OR" {
+			node = &filterNode{op: "OR", children: []*filterNode{node}}
+		}
+		node.children = append(node.children, right)
+	}
+	return node, nil
+}
+
+func (parser *filterParser) parseAnd() (*filterNode, error) {
+	node, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for next := parser.peek(); len(next) > 0 && next != "OR" && next != ")"; next = parser.peek() {
+		if next == "AND" {
+			parser.position++
+		}
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if node.op != "AND" {
+			node = &filterNode{op: "AND", children: []*filterNode{node}}
+		}
+		node.children = append(node.children, right)
+	}
+	return node, nil
+}
+
+func (parser *filterParser) parseNot() (*filterNode, error) {
+	if parser.peek() == "NOT" {
+		parser.position++
+		child, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNode{op: "NOT", children: []*filterNode{child}}, nil
+	}
+	return parser.parseTerm()
+}
+
+func (parser *filterParser) parseTerm() (*filterNode, error) {
+	token := parser.peek()
+	parser.position++
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case "(":
+		node, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parser.peek() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		parser.position++
+		return node, nil
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("unexpected '%s'", token)
+	}
+
+	node := &filterNode{op: "TERM", field: "tag", value: token}
+	if key, value, found := strings.Cut(token, "="); found {
+		switch key {
+		case "name", "region":
+			node.field, node.value = key, value
+		default:
+			// the value of key=value tags is the part which can be a pattern
+			node.key, node.value = key+"=", value
+		}
+	}
+	if len(node.value) == 0 {
+		return nil, fmt.Errorf("'%s' has no value", token)
+	}
+
+	switch {
+	case len(node.value) > 1 && strings.HasPrefix(node.value, "/") && strings.HasSuffix(node.value, "/"):
+		pattern, err := regexp.Compile(node.value[1 : len(node.value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression '%s': %v", node.value, err)
+		}
+		node.pattern = pattern
+	case strings.ContainsAny(node.value, "*?"):
+		node.pattern = globPattern(node.value)
+	}
+	return node, nil
+}
+
+// Compile a glob where '*' matches any text and '?' any single character
+func globPattern(glob string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, character := range glob {
+		switch character {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(character)))
+		}
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String())
+}
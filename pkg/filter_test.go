@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Render a parsed filter so the shape of the tree can be compared
+func renderFilterNode(node *filterNode) string {
+	switch node.op {
+	case "AND", "OR":
+		rendered := "(" + node.op
+		for _, child := range node.children {
+			rendered += " " + renderFilterNode(child)
+		}
+		return rendered + ")"
+	case "NOT":
+		return "(NOT " + renderFilterNode(node.children[0]) + ")"
+	}
+	return node.field + ":" + node.key + node.value
+}
+
+func TestParseAccountFilter(t *testing.T) {
+	cases := map[string]string{
+		"env=prod":                          "(AND tag:env=prod)",
+		"env=prod team=payments":            "(AND (AND tag:env=prod tag:team=payments))",
+		"a OR b AND c":                      "(AND (OR tag:a (AND tag:b tag:c)))",
+		"(a OR b) AND NOT c":                "(AND (AND (OR tag:a tag:b) (NOT tag:c)))",
+		"NOT NOT a":                         "(AND (NOT (NOT tag:a)))",
+		"name=prod-* region=/^eu-( |x)/":    "(AND (AND name:prod-* region:/^eu-( |x)/))",
+		`"team=payments and risk" OR other`: "(AND (OR tag:team=payments and risk tag:other))",
+		"":                                  "(AND)",
+	}
+	for expression, expected := range cases {
+		filter, err := ParseAccountFilter([]string{expression})
+		if err != nil {
+			t.Errorf("%q: %v", expression, err)
+			continue
+		}
+		if rendered := renderFilterNode(filter.root); rendered != expected {
+			t.Errorf("%q: parsed as %s, expected %s", expression, rendered, expected)
+		}
+	}
+}
+
+func TestParseAccountFilterErrors(t *testing.T) {
+	for _, expression := range []string{"(a OR b", "a)", "a AND", "OR a", "NOT", "env=", `"a`, "region=/^eu-", "name=/[/"} {
+		if _, err := ParseAccountFilter([]string{expression}); err == nil {
+			t.Errorf("%q: expected an error", expression)
+		}
+	}
+}
+
+func TestAccountFilterMatch(t *testing.T) {
+	account := &DynamoDbAccountConfig{Name: "prod-payments", Region: []string{"eu-west-1", "us-east-1"}, Tags: []string{"env=prod", "team=payments", "pci"}}
+	cases := map[string]bool{
+		"env=prod":                       true,
+		"env=dev":                        false,
+		"pci":                            true,
+		"env=prod AND NOT pci":           false,
+		"env=dev OR team=payments":       true,
+		"team=pay*":                      true,
+		"team=pay?":                      false,
+		"env=/^pr/":                      true,
+		"name=prod-*":                    true,
+		"name=prod-?":                    false,
+		"name=prod-payments":             true,
+		"region=us-east-1":               true,
+		"region=/^ap-/":                  false,
+		"env=prod (team=risk OR pci)":    true,
+		"NOT (env=prod AND team=risk)":   true,
+		"prod":                           false,
+		"env=prod AND region=/^eu-/ pci": true,
+	}
+	for expression, expected := range cases {
+		filter, err := ParseAccountFilter([]string{expression})
+		if err != nil {
+			t.Fatalf("%q: %v", expression, err)
+		}
+		if matched := filter.Match(account); matched != expected {
+			t.Errorf("%q: matched %v, expected %v", expression, matched, expected)
+		}
+	}
+}
+
+func TestAccountFilterRequiredTags(t *testing.T) {
+	filter, err := ParseAccountFilter([]string{"env=prod pci (team=a OR team=b)", "NOT deprecated", "team=pay*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags := filter.RequiredTags(); !slices.Equal(tags, []string{"env=prod", "pci"}) {
+		t.Errorf("required tags %v, expected [env=prod pci]", tags)
+	}
+}
+
+func TestAccountFilterDynamoDbExpression(t *testing.T) {
+	cases := []struct {
+		expression string
+		filter     string
+		values     map[string]string
+	}{
+		{"env=prod", "contains(#ftags, :f0)", map[string]string{":f0": "env=prod"}},
+		{"name=prod-*", "begins_with(#fname, :f0)", map[string]string{":f0": "prod-"}},
+		{"name=acct region=eu-west-1", "#fname = :f0 AND contains(#fregion, :f1)", map[string]string{":f0": "acct", ":f1": "eu-west-1"}},
+		{"NOT (pci OR team=risk)", "(NOT (contains(#ftags, :f0) OR contains(#ftags, :f1)))", map[string]string{":f0": "pci", ":f1": "team=risk"}},
+		// subtrees DynamoDB can not evaluate are left out along with their placeholders
+		{"env=prod AND (name=prod-* OR region=/^eu-/)", "contains(#ftags, :f0)", map[string]string{":f0": "env=prod"}},
+		{"(name=prod-* OR region=/^eu-/) AND env=prod", "contains(#ftags, :f0)", map[string]string{":f0": "env=prod"}},
+		{"NOT (pci AND team=*a*) region=us-east-1", "contains(#fregion, :f0)", map[string]string{":f0": "us-east-1"}},
+		{"team=/^pay/ OR pci", "", map[string]string{}},
+	}
+	for _, c := range cases {
+		filter, err := ParseAccountFilter([]string{c.expression})
+		if err != nil {
+			t.Fatalf("%q: %v", c.expression, err)
+		}
+		expression, names, values := filter.DynamoDbExpression()
+		if expression != c.filter {
+			t.Errorf("%q: expression %q, expected %q", c.expression, expression, c.filter)
+		}
+		actualValues := make(map[string]string)
+		for placeholder, value := range values {
+			actualValues[placeholder] = value.(*dynamodbTypes.AttributeValueMemberS).Value
+		}
+		if !maps.Equal(actualValues, c.values) {
+			t.Errorf("%q: values %v, expected %v", c.expression, actualValues, c.values)
+		}
+		// DynamoDB rejects names which are not used by the expression
+		var used []string
+		for placeholder := range names {
+			used = append(used, placeholder)
+		}
+		sort.Strings(used)
+		expected := regexp.MustCompile(`#f[a-z]+`).FindAllString(expression, -1)
+		sort.Strings(expected)
+		if !slices.Equal(used, slices.Compact(expected)) {
+			t.Errorf("%q: names %v, expression uses %v", c.expression, used, expected)
+		}
+	}
+}
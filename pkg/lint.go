@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Regions known to the catalog linter, regions launched after a letme release can be accepted with '--allow-region'
+var AwsRegions = []string{
+	"af-south-1",
+	"ap-east-1", "ap-east-2",
+	"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ap-south-1", "ap-south-2",
+	"ap-southeast-1", "ap-southeast-2", "ap-southeast-3", "ap-southeast-4", "ap-southeast-5", "ap-southeast-6", "ap-southeast-7",
+	"ca-central-1", "ca-west-1",
+	"cn-north-1", "cn-northwest-1",
+	"eu-central-1", "eu-central-2",
+	"eu-north-1",
+	"eu-south-1", "eu-south-2",
+	"eu-west-1", "eu-west-2", "eu-west-3",
+	"il-central-1",
+	"me-central-1", "me-south-1",
+	"mx-central-1",
+	"sa-east-1",
+	"us-east-1", "us-east-2",
+	"us-gov-east-1", "us-gov-west-1",
+	"us-west-1", "us-west-2",
+}
+
+// Rule checked by the catalog linter
+type CatalogLintRule struct {
+	Id          string `json:"id"`
+	Level       string `json:"level"`
+	Description string `json:"description"`
+}
+
+// Rules checked by the catalog linter, errors break letme commands while warnings are only bad practice
+var CatalogLintRules = []CatalogLintRule{
+	{Id: "duplicate-name", Level: "error", Description: "Account names must be unique, names only differing in case or surrounding spaces are confusing."},
+	{Id: "invalid-item", Level: "error", Description: "Items must have the attribute types letme expects."},
+	{Id: "missing-region", Level: "error", Description: "Accounts must have at least one region, 'list' and 'obtain' fail without it."},
+	{Id: "malformed-region", Level: "error", Description: "Regions must be well formed aws region names, 'obtain' rejects the others."},
+	{Id: "missing-role", Level: "error", Description: "Accounts must have a role chain or named roles."},
+	{Id: "invalid-role-arn", Level: "error", Description: "Role arns must not be empty and follow the arn:$PARTITION:iam::$ACCOUNT:role/$ROLE_NAME format."},
+	{Id: "chain-loop", Level: "error", Description: "Role chains must not assume the same role twice."},
+	{Id: "chain-revisit", Level: "warning", Description: "Role chains should not come back to an account they already left."},
+	{Id: "unknown-region", Level: "warning", Description: "Regions should be existing aws regions, a typo such as 'eu-westt-1' is only found when the account is used."},
+	{Id: "missing-tags", Level: "warning", Description: "Accounts should have tags so they can be filtered."},
+}
+
+// Problem found by the catalog linter on an account
+type CatalogLintFinding struct {
+	RuleId  string `json:"rule_id"`
+	Level   string `json:"level"`
+	Account string `json:"account"`
+	Message string `json:"message"`
+}
+
+// Check every catalog item against the lint rules. Regions on allowedRegions are accepted besides the known ones.
+func LintCatalogItems(items []map[string]dynamodbTypes.AttributeValue, allowedRegions []string) []CatalogLintFinding {
+	var findings []CatalogLintFinding
+	report := func(ruleId string, accountName string, message string, args ...interface{}) {
+		for _, rule := range CatalogLintRules {
+			if rule.Id == ruleId {
+				findings = append(findings, CatalogLintFinding{RuleId: ruleId, Level: rule.Level, Account: accountName, Message: fmt.Sprintf(message, args...)})
+			}
+		}
+	}
+
+	seen := make(map[string]string)
+	for _, item := range items {
+		accountName := CatalogItemName(item)
+		normalizedName := strings.ToLower(strings.TrimSpace(accountName))
+		if previous, ok := seen[normalizedName]; ok {
+			report("duplicate-name", accountName, "account '%s' has the same name as account '%s'", accountName, previous)
+		} else {
+			seen[normalizedName] = accountName
+		}
+
+		account, err := AccountFromItem(item)
+		if err != nil {
+			report("invalid-item", accountName, "account '%s' can not be read: %v", accountName, strings.TrimPrefix(err.Error(), "letme: "))
+			continue
+		}
+
+		if len(account.Region) == 0 {
+			report("missing-region", accountName, "account '%s' has no region", accountName)
+		}
+		for _, region := range account.Region {
+			switch {
+			case !ValidRegion(region):
+				report("malformed-region", accountName, "account '%s' has a malformed region '%s'", accountName, region)
+			case !slices.Contains(AwsRegions, region) && !slices.Contains(allowedRegions, region):
+				report("unknown-region", accountName, "account '%s' has an unknown region '%s'", accountName, region)
+			}
+		}
+
+		if len(account.Role) == 0 && len(account.Roles) == 0 {
+			report("missing-role", accountName, "account '%s' has no role", accountName)
+		}
+		chains := map[string][]RoleHop{"role": account.Role}
+		for roleName, chain := range account.Roles {
+			chains["role '"+roleName+"'"] = chain
+		}
+		chainNames := make([]string, 0, len(chains))
+		for chainName := range chains {
+			chainNames = append(chainNames, chainName)
+		}
+		sort.Strings(chainNames)
+		for _, chainName := range chainNames {
+			for _, problem := range lintRoleChain(chains[chainName]) {
+				report(problem[0], accountName, "account '%s' %s %s", accountName, chainName, problem[1])
+			}
+		}
+
+		if len(account.Tags) == 0 {
+			report("missing-tags", accountName, "account '%s' has no tags", accountName)
+		}
+	}
+	return findings
+}
+
+// Return the rule and message of every problem of a role chain
+func lintRoleChain(chain []RoleHop) [][2]string {
+	var problems [][2]string
+	var visitedAccounts []string
+	for i, hop := range chain {
+		switch {
+		case len(hop.Arn) == 0:
+			problems = append(problems, [2]string{"invalid-role-arn", fmt.Sprintf("hop %v has an empty role arn", i+1)})
+			continue
+		case !ValidRoleArn(hop.Arn):
+			problems = append(problems, [2]string{"invalid-role-arn", fmt.Sprintf("hop %v has a malformed role arn '%s'", i+1, hop.Arn)})
+			continue
+		}
+		loop := slices.IndexFunc(chain[:i], func(previous RoleHop) bool { return previous.Arn == hop.Arn })
+		if loop >= 0 {
+			problems = append(problems, [2]string{"chain-loop", fmt.Sprintf("hop %v assumes role '%s' again, already assumed on hop %v", i+1, hop.Arn, loop+1)})
+		}
+
+		// the account id is the fifth field of the arn
+		accountId := strings.Split(hop.Arn, ":")[4]
+		last := len(visitedAccounts) - 1
+		if loop < 0 && last >= 0 && visitedAccounts[last] != accountId && slices.Contains(visitedAccounts, accountId) {
+			problems = append(problems, [2]string{"chain-revisit", fmt.Sprintf("hop %v comes back to account %s", i+1, accountId)})
+		}
+		visitedAccounts = append(visitedAccounts, accountId)
+	}
+	return problems
+}
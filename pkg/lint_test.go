@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Malformed regions are errors, well formed regions letme does not know about are warnings unless allowed
+func TestLintCatalogRegions(t *testing.T) {
+	cases := map[string]string{
+		"eu-west-1":  "",
+		"eu-westt-1": "unknown-region",
+		"us-east-9":  "unknown-region",
+		"xx-new-1":   "",
+		"eu_west_1":  "malformed-region",
+	}
+	for region, expected := range cases {
+		items := []map[string]dynamodbTypes.AttributeValue{{
+			"name":   &dynamodbTypes.AttributeValueMemberS{Value: "acct"},
+			"region": &dynamodbTypes.AttributeValueMemberL{Value: []dynamodbTypes.AttributeValue{&dynamodbTypes.AttributeValueMemberS{Value: region}}},
+			"role":   &dynamodbTypes.AttributeValueMemberL{Value: []dynamodbTypes.AttributeValue{&dynamodbTypes.AttributeValueMemberS{Value: "arn:aws:iam::111111111111:role/a"}}},
+			"tags":   &dynamodbTypes.AttributeValueMemberL{Value: []dynamodbTypes.AttributeValue{&dynamodbTypes.AttributeValueMemberS{Value: "prod"}}},
+		}}
+		var found string
+		for _, finding := range LintCatalogItems(items, []string{"xx-new-1"}) {
+			found = finding.RuleId
+		}
+		if found != expected {
+			t.Errorf("%q: found %q, expected %q", region, found, expected)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Maximum number of accounts drawn at once by the picker
+const pickerMaxRows = 10
+
+// Check if letme is attached to an interactive terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// Sort accounts placing favorites first followed by the most recently obtained ones, the rest are sorted by name
+func SortAccountsByRecentUse(accountList []DynamoDbAccountConfig) []DynamoDbAccountConfig {
+	lastUsed := make(map[string]int64)
+	favorite := make(map[string]bool)
+	for _, entry := range GetAccountUsage(GetCurrentContext()) {
+		lastUsed[entry.Name] = entry.LastUsed
+		favorite[entry.Name] = entry.Favorite
+	}
+
+	sorted := make([]DynamoDbAccountConfig, len(accountList))
+	copy(sorted, accountList)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if favorite[sorted[i].Name] != favorite[sorted[j].Name] {
+			return favorite[sorted[i].Name]
+		}
+		if lastUsed[sorted[i].Name] != lastUsed[sorted[j].Name] {
+			return lastUsed[sorted[i].Name] > lastUsed[sorted[j].Name]
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// Text shown for an account in the picker, which is also the text matched against the query
+func pickerLine(account DynamoDbAccountConfig) string {
+	return account.Name + "  " + AccountRegion(&account) + "  " + strings.Join(account.Tags, ",")
+}
+
+// Check if every character of the query appears in order within text
+func fuzzyMatch(text string, query string) bool {
+	i := 0
+	for _, r := range text {
+		if i < len(query) && r == rune(query[i]) {
+			i++
+		}
+	}
+	return i == len(query)
+}
+
+// Score how well an account matches the query, zero means no match. Exact prefixes and substrings
+// of the account name rank before fuzzy matches over the name, region and tags.
+func fuzzyScore(account DynamoDbAccountConfig, query string) int {
+	query = strings.ToLower(query)
+	name := strings.ToLower(account.Name)
+	switch {
+	case len(query) == 0:
+		return 1
+	case strings.HasPrefix(name, query):
+		return 5
+	case strings.Contains(name, query):
+		return 4
+	case fuzzyMatch(name, query):
+		return 3
+	case strings.Contains(strings.ToLower(pickerLine(account)), query):
+		return 2
+	case fuzzyMatch(strings.ToLower(pickerLine(account)), query):
+		return 1
+	}
+	return 0
+}
+
+// Filter the accounts matching the query, keeping the given order between accounts with the same score
+func FuzzyFilterAccounts(accountList []DynamoDbAccountConfig, query string) []DynamoDbAccountConfig {
+	var matches []DynamoDbAccountConfig
+	scores := make(map[string]int)
+	for _, account := range accountList {
+		if score := fuzzyScore(account, query); score > 0 {
+			scores[account.Name] = score
+			matches = append(matches, account)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return scores[matches[i].Name] > scores[matches[j].Name]
+	})
+	return matches
+}
+
+// Open an interactive fuzzy finder over the accounts and return the selected account name.
+// The picker is drawn on stderr so stdout can still be captured, an empty string is returned if the
+// user cancels the selection. Errors are returned once the terminal is restored.
+func PickAccount(accountList []DynamoDbAccountConfig) (string, error) {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, oldState)
+
+	accounts := SortAccountsByRecentUse(accountList)
+	query := ""
+	selected := 0
+	drawnLines := 0
+	buf := make([]byte, 16)
+
+	for {
+		matches := FuzzyFilterAccounts(accounts, query)
+		if selected >= len(matches) {
+			selected = len(matches) - 1
+		}
+		if selected < 0 {
+			selected = 0
+		}
+
+		rows := pickerMaxRows
+		if _, height, err := term.GetSize(fd); err == nil && height > 2 && height-2 < rows {
+			rows = height - 2
+		}
+		offset := 0
+		if selected >= rows {
+			offset = selected - rows + 1
+		}
+
+		// redraw the picker on top of the previous one
+		var screen strings.Builder
+		if drawnLines > 0 {
+			fmt.Fprintf(&screen, "\033[%dA", drawnLines)
+		}
+		screen.WriteString("\r\033[J")
+		fmt.Fprintf(&screen, "Select an account (%v/%v) → %s\r\n", len(matches), len(accounts), query)
+		drawnLines = 1
+		for i := offset; i < len(matches) && i < offset+rows; i++ {
+			if i == selected {
+				screen.WriteString("\033[7m> " + pickerLine(matches[i]) + "\033[0m\r\n")
+			} else {
+				screen.WriteString("  " + pickerLine(matches[i]) + "\r\n")
+			}
+			drawnLines++
+		}
+		os.Stderr.WriteString(screen.String())
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\033[%dA\r\033[J", drawnLines)
+			return "", err
+		}
+		key := string(buf[:n])
+
+		switch {
+		case key == "\r" || key == "\n":
+			fmt.Fprintf(os.Stderr, "\033[%dA\r\033[J", drawnLines)
+			if len(matches) == 0 {
+				return "", nil
+			}
+			return matches[selected].Name, nil
+		case key == "\x03" || key == "\x1b" || key == "\x04":
+			fmt.Fprintf(os.Stderr, "\033[%dA\r\033[J", drawnLines)
+			return "", nil
+		case key == "\x1b[A" || key == "\x10":
+			selected--
+		case key == "\x1b[B" || key == "\x0e":
+			selected++
+		case key == "\x7f" || key == "\b":
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				selected = 0
+			}
+		case key == "\x15":
+			query = ""
+			selected = 0
+		case isPrintable(key):
+			query += key
+			selected = 0
+		}
+	}
+}
+
+// Check if the input read from the terminal only contains printable ascii characters
+func isPrintable(input string) bool {
+	for i := 0; i < len(input); i++ {
+		if input[i] < ' ' || input[i] > '~' {
+			return false
+		}
+	}
+	return len(input) > 0
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// The inverted tag items live on their own table, named after the catalog table with this suffix. Keeping them out
+// of the catalog table means scans of the catalog, including the ones of older letme versions, only return accounts.
+const TagIndexTableSuffix = "-tag-index"
+
+// Key attributes of the tag index table, inverted tag items are keyed by tag and account name
+const (
+	TagIndexTagAttribute     = "tag"
+	TagIndexAccountAttribute = "account"
+)
+
+// Maximum number of keys dynamodb accepts on a single BatchGetItem call
+const batchGetSize = 100
+
+// Return the name of the table holding the tag index of a catalog table
+func TagIndexTable(awsDynamoDbTable string) string {
+	return awsDynamoDbTable + TagIndexTableSuffix
+}
+
+// Return the inverted tag item of an account tag, which is also its key on the tag index table
+func TagIndexItem(tag string, accountName string) map[string]dynamodbTypes.AttributeValue {
+	return map[string]dynamodbTypes.AttributeValue{
+		TagIndexTagAttribute:     &dynamodbTypes.AttributeValueMemberS{Value: tag},
+		TagIndexAccountAttribute: &dynamodbTypes.AttributeValueMemberS{Value: accountName},
+	}
+}
+
+// Return the tags of a catalog item, items without valid tags have none
+func CatalogItemTags(item map[string]dynamodbTypes.AttributeValue) []string {
+	account, err := AccountFromItem(item)
+	if err != nil {
+		return nil
+	}
+	return account.Tags
+}
+
+// Return the inverted tag items to write and the keys of the ones to delete when the tags of an account change
+func TagIndexChanges(accountName string, previousTags []string, tags []string) ([]map[string]dynamodbTypes.AttributeValue, []map[string]dynamodbTypes.AttributeValue) {
+	var puts, deletes []map[string]dynamodbTypes.AttributeValue
+	for _, tag := range tags {
+		if !slices.Contains(previousTags, tag) {
+			puts = append(puts, TagIndexItem(tag, accountName))
+		}
+	}
+	for _, tag := range previousTags {
+		if !slices.Contains(tags, tag) {
+			deletes = append(deletes, TagIndexItem(tag, accountName))
+		}
+	}
+	return puts, deletes
+}
+
+// Check if a catalog table has a tag index table, catalogs without it are looked up by tag with a scan
+func HasTagIndex(awsDynamoDbTable string, cfg aws.Config) (bool, error) {
+	_, err := dynamodb.NewFromConfig(cfg).DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{
+		TableName: aws.String(TagIndexTable(awsDynamoDbTable)),
+	})
+	if isMissingTagIndex(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Write and delete the inverted tag items of an account so they match its new tags. Does nothing on catalogs
+// without a tag index table.
+func SyncTagIndexItems(awsDynamoDbTable string, cfg aws.Config, accountName string, previousTags []string, tags []string) error {
+	hasTagIndex, err := HasTagIndex(awsDynamoDbTable, cfg)
+	if err != nil || !hasTagIndex {
+		return err
+	}
+	puts, deletes := TagIndexChanges(accountName, previousTags, tags)
+	return BatchWriteItems(TagIndexTable(awsDynamoDbTable), cfg, puts, deletes)
+}
+
+// Return a string identifying an inverted tag item, tags can not contain '#' so it is never ambiguous
+func tagIndexItemId(item map[string]dynamodbTypes.AttributeValue) string {
+	var tag, accountName string
+	if value, ok := item[TagIndexTagAttribute].(*dynamodbTypes.AttributeValueMemberS); ok {
+		tag = value.Value
+	}
+	if value, ok := item[TagIndexAccountAttribute].(*dynamodbTypes.AttributeValueMemberS); ok {
+		accountName = value.Value
+	}
+	return tag + "#" + accountName
+}
+
+// Rebuild the tag index table of a catalog from the tags of its accounts, returning the number of inverted tag items
+// written and deleted. Both tables are scanned in the given number of segments, see ScanTable.
+func RebuildTagIndex(awsDynamoDbTable string, cfg aws.Config, segments int) (int, int, error) {
+	items, err := ScanCatalogItems(awsDynamoDbTable, cfg, segments)
+	if err != nil {
+		return 0, 0, err
+	}
+	wanted := make(map[string]map[string]dynamodbTypes.AttributeValue)
+	for _, item := range items {
+		account, err := AccountFromItem(item)
+		if err != nil {
+			return 0, 0, err
+		}
+		puts, _ := TagIndexChanges(account.Name, nil, account.Tags)
+		for _, put := range puts {
+			wanted[tagIndexItemId(put)] = put
+		}
+	}
+
+	existing, err := ScanTable(cfg, &dynamodb.ScanInput{
+		TableName:      aws.String(TagIndexTable(awsDynamoDbTable)),
+		ConsistentRead: aws.Bool(true),
+	}, segments)
+	if err != nil {
+		return 0, 0, err
+	}
+	var deletes []map[string]dynamodbTypes.AttributeValue
+	for _, item := range existing {
+		if _, ok := wanted[tagIndexItemId(item)]; ok {
+			delete(wanted, tagIndexItemId(item))
+		} else {
+			deletes = append(deletes, item)
+		}
+	}
+	var puts []map[string]dynamodbTypes.AttributeValue
+	for _, put := range wanted {
+		puts = append(puts, put)
+	}
+	return len(puts), len(deletes), BatchWriteItems(TagIndexTable(awsDynamoDbTable), cfg, puts, deletes)
+}
+
+// Return the names of the accounts with a tag, querying the tag index table
+func queryTagIndex(sesAwsDynamoDb *dynamodb.Client, awsDynamoDbTable string, tag string) ([]string, error) {
+	var accountNames []string
+	paginator := dynamodb.NewQueryPaginator(sesAwsDynamoDb, &dynamodb.QueryInput{
+		TableName:                 aws.String(TagIndexTable(awsDynamoDbTable)),
+		KeyConditionExpression:    aws.String("#tag = :tag"),
+		ExpressionAttributeNames:  map[string]string{"#tag": TagIndexTagAttribute},
+		ExpressionAttributeValues: map[string]dynamodbTypes.AttributeValue{":tag": &dynamodbTypes.AttributeValueMemberS{Value: tag}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if accountName, ok := item[TagIndexAccountAttribute].(*dynamodbTypes.AttributeValueMemberS); ok {
+				accountNames = append(accountNames, accountName.Value)
+			}
+		}
+	}
+	return accountNames, nil
+}
+
+// Return the accounts containing every tag by querying the tag index table, then reading the matching accounts only.
+// When attributes are given only those are fetched.
+func QueryAccountsByTags(awsDynamoDbTable string, tags []string, cfg aws.Config, attributes []string) ([]map[string]dynamodbTypes.AttributeValue, error) {
+	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+	var accountNames []string
+	for i, tag := range tags {
+		tagAccounts, err := queryTagIndex(sesAwsDynamoDb, awsDynamoDbTable, tag)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			accountNames = tagAccounts
+			continue
+		}
+		accountNames = slices.DeleteFunc(accountNames, func(accountName string) bool {
+			return !slices.Contains(tagAccounts, accountName)
+		})
+	}
+	sort.Strings(accountNames)
+	accountNames = slices.Compact(accountNames)
+
+	keysAndAttributes := dynamodbTypes.KeysAndAttributes{}
+	if len(attributes) > 0 {
+		input := &dynamodb.ScanInput{ExpressionAttributeNames: make(map[string]string)}
+		projectAttributes(input, attributes)
+		keysAndAttributes.ProjectionExpression = input.ProjectionExpression
+		keysAndAttributes.ExpressionAttributeNames = input.ExpressionAttributeNames
+	}
+
+	var items []map[string]dynamodbTypes.AttributeValue
+	for start := 0; start < len(accountNames); start += batchGetSize {
+		batch := keysAndAttributes
+		batch.Keys = nil
+		for _, accountName := range accountNames[start:min(start+batchGetSize, len(accountNames))] {
+			batch.Keys = append(batch.Keys, map[string]dynamodbTypes.AttributeValue{"name": &dynamodbTypes.AttributeValueMemberS{Value: accountName}})
+		}
+		pending := map[string]dynamodbTypes.KeysAndAttributes{awsDynamoDbTable: batch}
+		for attempt := 0; len(pending[awsDynamoDbTable].Keys) > 0; attempt++ {
+			if attempt > batchWriteRetries {
+				return nil, fmt.Errorf("letme: %v items were left unprocessed by DynamoDB after %v retries", len(pending[awsDynamoDbTable].Keys), batchWriteRetries)
+			}
+			if attempt > 0 {
+				time.Sleep(time.Duration(50<<attempt) * time.Millisecond)
+			}
+			resp, err := sesAwsDynamoDb.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{RequestItems: pending})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, resp.Responses[awsDynamoDbTable]...)
+			pending = resp.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
+
+// Check if a request failed because the catalog has no tag index table
+func isMissingTagIndex(err error) bool {
+	var resourceNotFound *dynamodbTypes.ResourceNotFoundException
+	return errors.As(err, &resourceNotFound)
+}
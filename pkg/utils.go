@@ -2,8 +2,12 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -13,16 +17,19 @@ import (
 	dynamodbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/aws/smithy-go"
 	"gopkg.in/ini.v1"
 )
 
@@ -35,6 +42,14 @@ var ExpectedKeys = map[string]bool{
 	"session_name":              true,
 	"session_duration":          true,
 	"tags":                      true,
+	"external_id":               true,
+	"source_identity":           true,
+	"session_tags":              true,
+	"transitive_tag_keys":       true,
+	"default_role":              true,
+	"profile_template":          true,
+	"dynamodb_scan_segments":    true,
+	"dynamodb_tag_index":        true,
 }
 
 // Mandatory keys in letme-config file
@@ -49,25 +64,93 @@ type Context struct {
 }
 
 type LetmeContext struct {
-	AwsSourceProfile       string   `ini:"aws_source_profile"`
-	AwsSourceProfileRegion string   `ini:"aws_source_profile_region"`
-	AwsDynamoDbTable       string   `ini:"dynamodb_table"`
-	AwsMfaArn              string   `ini:"mfa_arn"`
-	AwsSessionName         string   `ini:"session_name"`
-	AwsSessionDuration     int32    `ini:"session_duration"`
-	Tags                   []string `ini:"tags"`
+	AwsSourceProfile        string   `ini:"aws_source_profile"`
+	AwsSourceProfileRegion  string   `ini:"aws_source_profile_region"`
+	AwsDynamoDbTable        string   `ini:"dynamodb_table"`
+	AwsMfaArn               string   `ini:"mfa_arn"`
+	AwsSessionName          string   `ini:"session_name"`
+	AwsSessionDuration      int32    `ini:"session_duration"`
+	Tags                    []string `ini:"tags"`
+	AwsExternalId           string   `ini:"external_id"`
+	AwsSourceIdentity       string   `ini:"source_identity"`
+	AwsSessionTags          []string `ini:"session_tags"`
+	AwsTransitiveTagKeys    []string `ini:"transitive_tag_keys"`
+	AwsDefaultRole          string   `ini:"default_role"`
+	AwsProfileTemplate      string   `ini:"profile_template"`
+	AwsDynamoDbScanSegments int      `ini:"dynamodb_scan_segments"`
+	AwsDynamoDbTagIndex     bool     `ini:"dynamodb_tag_index"`
 }
 
 type DynamoDbAccountConfig struct {
-	Name   string   `dynamodbav:"name"`
-	Region []string `dynamodbav:"region"`
-	Role   []string `dynamodbav:"role"`
-	Tags   []string `dynamodbav:"tags"`
+	Name              string               `dynamodbav:"name"`
+	Region            []string             `dynamodbav:"region"`
+	Role              []RoleHop            `dynamodbav:"role"`
+	Tags              []string             `dynamodbav:"tags"`
+	ExternalId        string               `dynamodbav:"external_id,omitempty"`
+	SourceIdentity    string               `dynamodbav:"source_identity,omitempty"`
+	SessionTags       map[string]string    `dynamodbav:"session_tags,omitempty"`
+	TransitiveTagKeys []string             `dynamodbav:"transitive_tag_keys,omitempty"`
+	SessionPolicy     string               `dynamodbav:"session_policy,omitempty"`
+	SessionPolicyArns []string             `dynamodbav:"session_policy_arns,omitempty"`
+	Roles             map[string][]RoleHop `dynamodbav:"roles,omitempty"`
+	DefaultRole       string               `dynamodbav:"default_role,omitempty"`
+	SelectedRole      string               `dynamodbav:"-"`
+	SelectedRegion    string               `dynamodbav:"-"`
+}
+
+// Struct which states a hop of the role chain. Hops are stored either as a plain role arn or as a map
+// overriding the external id, session name, session duration, sts region or MFA requirement of that hop.
+type RoleHop struct {
+	Arn             string `dynamodbav:"arn"`
+	ExternalId      string `dynamodbav:"external_id,omitempty"`
+	SessionName     string `dynamodbav:"session_name,omitempty"`
+	SessionDuration int32  `dynamodbav:"duration,omitempty"`
+	Region          string `dynamodbav:"region,omitempty"`
+	Mfa             *bool  `dynamodbav:"mfa,omitempty"`
+}
+
+// Alias without the custom (un)marshalling methods, used to avoid infinite recursion
+type roleHop RoleHop
+
+// Unmarshal a hop stored either as a role arn string or as a map
+func (hop *RoleHop) UnmarshalDynamoDBAttributeValue(av dynamodbTypes.AttributeValue) error {
+	switch value := av.(type) {
+	case *dynamodbTypes.AttributeValueMemberS:
+		*hop = RoleHop{Arn: value.Value}
+	case *dynamodbTypes.AttributeValueMemberM:
+		var decoded roleHop
+		if err := attributevalue.UnmarshalMap(value.Value, &decoded); err != nil {
+			return err
+		}
+		*hop = RoleHop(decoded)
+	default:
+		return fmt.Errorf("letme: role hops must be a string or a map, got %T", av)
+	}
+	return nil
+}
+
+// Marshal a hop as a plain role arn string unless it overrides any setting
+func (hop RoleHop) MarshalDynamoDBAttributeValue() (dynamodbTypes.AttributeValue, error) {
+	if hop == (RoleHop{Arn: hop.Arn}) {
+		return &dynamodbTypes.AttributeValueMemberS{Value: hop.Arn}, nil
+	}
+	return attributevalue.Marshal(roleHop(hop))
+}
+
+// Struct which states the optional AssumeRole parameters merged from the context and the account item
+type AssumeRoleOptions struct {
+	ExternalId        string
+	SourceIdentity    string
+	SessionTags       map[string]string
+	TransitiveTagKeys []string
+	Policy            string
+	PolicyArns        []string
 }
 
 type AccountItem struct {
-	Name   string `json:"name"`
-	Region string `json:"region"`
+	Name   string   `json:"name"`
+	Region string   `json:"region"`
+	Roles  []string `json:"roles,omitempty"`
 }
 
 type AccountItems struct {
@@ -116,6 +199,27 @@ func CheckConfigFile(path string) bool {
 				return false
 			}
 		}
+
+		if section.HasKey("profile_template") {
+			if err := ValidateProfileTemplate(section.Key("profile_template").String()); err != nil {
+				fmt.Println(err)
+				return false
+			}
+		}
+
+		if section.HasKey("dynamodb_scan_segments") {
+			if segments, err := section.Key("dynamodb_scan_segments").Int(); err != nil || segments < 1 || segments > MaxScanSegments {
+				fmt.Printf("letme: key 'dynamodb_scan_segments' in table '%s' must be a number between 1 and %v.\n", section.Name(), MaxScanSegments)
+				return false
+			}
+		}
+
+		if section.HasKey("dynamodb_tag_index") {
+			if _, err := section.Key("dynamodb_tag_index").Bool(); err != nil {
+				fmt.Printf("letme: key 'dynamodb_tag_index' in table '%s' must be true or false.\n", section.Name())
+				return false
+			}
+		}
 	}
 
 	return true
@@ -378,6 +482,19 @@ func NewContext(context string, mode int) {
 	letmeContext.AwsSessionName = sessionNameInput()
 	letmeContext.Tags = letmeTagsInput()
 
+	// keys without a prompt are kept when updating a context
+	if mode == 1 {
+		currentContext := GetContextData(context)
+		letmeContext.AwsExternalId = currentContext.AwsExternalId
+		letmeContext.AwsSourceIdentity = currentContext.AwsSourceIdentity
+		letmeContext.AwsSessionTags = currentContext.AwsSessionTags
+		letmeContext.AwsTransitiveTagKeys = currentContext.AwsTransitiveTagKeys
+		letmeContext.AwsDefaultRole = currentContext.AwsDefaultRole
+		letmeContext.AwsProfileTemplate = currentContext.AwsProfileTemplate
+		letmeContext.AwsDynamoDbScanSegments = currentContext.AwsDynamoDbScanSegments
+		letmeContext.AwsDynamoDbTagIndex = currentContext.AwsDynamoDbTagIndex
+	}
+
 	letmeConfig := LetmeConfigRead()
 
 	section := letmeConfig.Section(context)
@@ -393,6 +510,18 @@ func NewContext(context string, mode int) {
 	if len(letmeContext.Tags) == 0 {
 		section.DeleteKey("tags")
 	}
+
+	for _, key := range []string{"external_id", "source_identity", "session_tags", "transitive_tag_keys", "default_role", "profile_template"} {
+		if len(section.Key(key).String()) == 0 {
+			section.DeleteKey(key)
+		}
+	}
+	if letmeContext.AwsDynamoDbScanSegments == 0 {
+		section.DeleteKey("dynamodb_scan_segments")
+	}
+	if !letmeContext.AwsDynamoDbTagIndex {
+		section.DeleteKey("dynamodb_tag_index")
+	}
 	letmeConfig.SaveTo(GetHomeDirectory() + "/.letme/letme-config")
 }
 
@@ -413,35 +542,20 @@ func CacheFileExists() bool {
 }
 
 // Marshalls data into a string used for the aws config file but with the v1 output protocol
-func AwsConfigFileCredentialsProcessV1(accountName string, region string) {
+func AwsConfigFileCredentialsProcessV1(account *DynamoDbAccountConfig, region string) {
+	accountName := GetProfileName(account)
 	credentials := AwsCredsFileReadV2()
 	config := AwsConfigFileReadV2()
 
-	accountInFile := CheckAccountLocally(accountName)
-	switch {
-	case accountInFile["credentials"]:
+	if credentials.HasSection(accountName) {
 		credentials.DeleteSection(accountName)
-		err := credentials.SaveTo(GetHomeDirectory() + "/.aws/credentials")
+		err := SaveAwsFile(credentials, GetHomeDirectory()+"/.aws/credentials")
 		fmt.Println("letme: removed profile '" + accountName + "' entry from credentials file.")
 		CheckAndReturnError(err)
-		fallthrough
-	case accountInFile["config"] && !config.Section("profile "+accountName).HasKey("credential_process"):
-		_, err := config.Section("profile "+accountName).NewKey("credential_process", "letme obtain "+accountName+" --v1")
-		CheckAndReturnError(err)
-		err = config.SaveTo(GetHomeDirectory() + "/.aws/config")
-		CheckAndReturnError(err)
-	default:
-		section, errSection := config.NewSection("profile " + accountName)
-		CheckAndReturnError(errSection)
-		_, errCredentialProcess := section.NewKey("credential_process", "letme obtain "+accountName+" --v1")
-		CheckAndReturnError(errCredentialProcess)
-		_, errRegion := section.NewKey("region", region)
-		CheckAndReturnError(errRegion)
-		_, errOutput := section.NewKey("output", "json")
-		CheckAndReturnError(errOutput)
-		err := config.SaveTo(GetHomeDirectory() + "/.aws/config")
-		CheckAndReturnError(err)
 	}
+	setCredentialProcessProfile(config.Section("profile "+accountName), account, region)
+	err := SaveAwsFile(config, GetHomeDirectory()+"/.aws/config")
+	CheckAndReturnError(err)
 
 	fmt.Println("letme: configured credential process V1 for account " + accountName)
 	fmt.Println("letme: use the argument '--profile " + accountName + "' to interact with the account.")
@@ -499,14 +613,20 @@ type Dataset struct {
 	Expiry        int64  `json:"expiry"`
 	AuthMethod    string `json:"authMethod"`
 	V1Credentials string `json:"v1Credentials,omitempty"`
+	Context       string `json:"context,omitempty"`
+	SessionPolicy string `json:"sessionPolicy,omitempty"`
 }
 type Account struct {
 	Account Dataset `json:"account"`
 }
 
-// Create a file which stores the last time when credentials where requested. Then query if the account exists,
-// if not, it will create its first entry.
-func DatabaseFile(accountName string, sessionDuration int32, v1Credentials string, authMethod string) {
+// Create a file which stores the last time when credentials where requested and when they expire. Then query if the
+// account exists, if not, it will create its first entry.
+func DatabaseFile(accountName string, expiry time.Time, v1Credentials string, authMethod string, sessionPolicy string) {
+	if DryRun {
+		fmt.Println("letme: dry run, would cache credentials for '" + accountName + "' until " + expiry.Local().Format(time.RFC1123) + ".")
+		return
+	}
 	databaseFileWriter, err := os.OpenFile(GetHomeDirectory()+"/.letme/.letme-db", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
 	CheckAndReturnError(err)
 	databaseFileReader, err := os.ReadFile(GetHomeDirectory() + "/.letme/.letme-db")
@@ -528,9 +648,11 @@ func DatabaseFile(accountName string, sessionDuration int32, v1Credentials strin
 			//when file is populated and client exist, just update fields
 			if idents[i].Account.Name == accountName {
 				idents[i].Account.LastRequest = time.Now().Unix()
-				idents[i].Account.Expiry = time.Now().Add(time.Second * time.Duration(sessionDuration)).Unix()
+				idents[i].Account.Expiry = expiry.Unix()
 				idents[i].Account.V1Credentials = v1Credentials
+				idents[i].Account.SessionPolicy = sessionPolicy
 				idents[i].Account.AuthMethod = authMethod
+				idents[i].Account.Context = GetCurrentContext()
 				b, err := json.MarshalIndent(idents, "", "  ")
 				CheckAndReturnError(err)
 
@@ -542,7 +664,7 @@ func DatabaseFile(accountName string, sessionDuration int32, v1Credentials strin
 			}
 		}
 		//when file is populated but client does not exist
-		idents = append(idents, Account{Dataset{accountName, time.Now().Unix(), time.Now().Add(time.Second * time.Duration(sessionDuration)).Unix(), authMethod, v1Credentials}})
+		idents = append(idents, Account{Dataset{accountName, time.Now().Unix(), expiry.Unix(), authMethod, v1Credentials, GetCurrentContext(), sessionPolicy}})
 		b, err := json.MarshalIndent(idents, "", "  ")
 		CheckAndReturnError(err)
 
@@ -552,7 +674,7 @@ func DatabaseFile(accountName string, sessionDuration int32, v1Credentials strin
 		}
 		//when file does not exist neither the client
 	} else if fi.Size() == 0 {
-		idents = append(idents, Account{Dataset{accountName, time.Now().Unix(), time.Now().Add(time.Second * time.Duration(sessionDuration)).Unix(), authMethod, v1Credentials}})
+		idents = append(idents, Account{Dataset{accountName, time.Now().Unix(), expiry.Unix(), authMethod, v1Credentials, GetCurrentContext(), sessionPolicy}})
 		b, err := json.MarshalIndent(idents, "", "  ")
 		CheckAndReturnError(err)
 
@@ -589,7 +711,7 @@ func CheckAccountAvailability(accountName string) bool {
 				}
 			}
 		}
-	} else {
+	} else if !DryRun {
 		_, err := os.OpenFile(GetHomeDirectory()+"/.letme/.letme-db", os.O_CREATE, 0600)
 		CheckAndReturnError(err)
 	}
@@ -620,6 +742,10 @@ func ReturnAccountCredentials(accountName string) map[string]string {
 
 // Remove an account from the database file
 func RemoveAccountFromDatabaseFile(accountName string) {
+	if DryRun {
+		fmt.Println("letme: dry run, would remove cached credentials for '" + accountName + "'.")
+		return
+	}
 	jsonData, err := os.ReadFile(GetHomeDirectory() + "/.letme/.letme-db")
 	CheckAndReturnError(err)
 	//unmarshal JSON data into a slice of maps
@@ -647,14 +773,218 @@ func RemoveAccountFromDatabaseFile(accountName string) {
 	}
 }
 
+// Read all the entries stored in the database file, a missing or empty file returns no entries
+func ReadDatabaseFile() []Account {
+	var idents []Account
+	databaseFileReader, err := os.ReadFile(GetHomeDirectory() + "/.letme/.letme-db")
+	if os.IsNotExist(err) {
+		return idents
+	}
+	CheckAndReturnError(err)
+	if len(databaseFileReader) == 0 {
+		return idents
+	}
+	if !json.Valid(databaseFileReader) {
+		fmt.Printf("letme: " + GetHomeDirectory() + "/.letme/.letme-db" + " is not JSON valid. Remove the file and try again.\n")
+		os.Exit(1)
+	}
+	err = json.Unmarshal(databaseFileReader, &idents)
+	CheckAndReturnError(err)
+	return idents
+}
+
+// Overwrite the database file with the given entries
+func WriteDatabaseFile(idents []Account) {
+	if DryRun {
+		remaining := make(map[string]bool)
+		for _, ident := range idents {
+			remaining[ident.Account.Name] = true
+		}
+		for _, ident := range ReadDatabaseFile() {
+			if !remaining[ident.Account.Name] {
+				fmt.Println("letme: dry run, would remove cached credentials for '" + ident.Account.Name + "'.")
+			}
+		}
+		return
+	}
+	if idents == nil {
+		idents = []Account{}
+	}
+	b, err := json.MarshalIndent(idents, "", "  ")
+	CheckAndReturnError(err)
+	if err := os.WriteFile(GetHomeDirectory()+"/.letme/.letme-db", b, 0600); err != nil {
+		CheckAndReturnError(err)
+	}
+}
+
+// Return the letme command used as credential_process for an account. The context is pinned so the profile keeps
+// working after switching to another context.
+func CredentialProcessCommand(account *DynamoDbAccountConfig) string {
+	if len(account.SelectedRole) > 0 {
+		return "letme obtain " + account.Name + " --context " + GetCurrentContext() + " --role " + account.SelectedRole + " --v1"
+	}
+	return "letme obtain " + account.Name + " --context " + GetCurrentContext() + " --v1"
+}
+
+// Return the account and context of a profile whose credential_process is a letme obtain command
+func CredentialProcessAccount(section *ini.Section) (string, string, bool) {
+	if !section.HasKey("credential_process") {
+		return "", "", false
+	}
+	fields := strings.Fields(section.Key("credential_process").String())
+	if len(fields) < 3 || fields[0] != "letme" || (fields[1] != "obtain" && fields[1] != "ob") {
+		return "", "", false
+	}
+	context := ""
+	if index := slices.Index(fields, "--context"); index != -1 && index+1 < len(fields) {
+		context = fields[index+1]
+	}
+	return fields[2], context, true
+}
+
+// Write a credential_process profile for an account on the loaded aws config file, replacing any previous letme
+// managed profile with the same name. Returns the profile name, or false if the profile is not managed by letme.
+func AwsConfigFileCredentialProcessProfile(configFile *ini.File, account *DynamoDbAccountConfig) (string, bool) {
+	profileName := GetProfileName(account)
+	if section, err := configFile.GetSection("profile " + profileName); err == nil && !IsLetmeManaged(section) {
+		return profileName, false
+	}
+
+	setCredentialProcessProfile(configFile.Section("profile "+profileName), account, AccountRegion(account))
+	return profileName, true
+}
+
+// Replace the keys of an aws config file section with a credential_process profile for an account
+func setCredentialProcessProfile(section *ini.Section, account *DynamoDbAccountConfig, region string) {
+	ClaimLetmeSection(section)
+	section.Key("credential_process").SetValue(CredentialProcessCommand(account))
+	section.Key("region").SetValue(region)
+	section.Key("output").SetValue("json")
+}
+
+// Comment which marks the sections of the aws credentials/config files written by letme
+const letmeManagedComment = "letme managed"
+
+// Split the comment of a section of the aws credentials/config files in its last line, where letme writes its
+// marker, and the lines above it. Every comment line above a section header is part of the section comment.
+func splitSectionComment(section *ini.Section) ([]string, string) {
+	lines := strings.Split(strings.ReplaceAll(section.Comment, "\r\n", "\n"), "\n")
+	return lines[:len(lines)-1], lines[len(lines)-1]
+}
+
+// Return the letme marker of a section without its comment character, or an empty string if it has none
+func letmeManagedMarker(section *ini.Section) string {
+	_, last := splitSectionComment(section)
+	last = strings.TrimSpace(strings.TrimLeft(last, "#;"))
+	if !strings.HasPrefix(last, letmeManagedComment) {
+		return ""
+	}
+	return last
+}
+
+// Check if a section of the aws credentials/config files was written by letme. Credential process profiles written
+// by older versions of letme were not marked, so they are recognized by their letme obtain command.
+func IsLetmeManaged(section *ini.Section) bool {
+	if len(letmeManagedMarker(section)) > 0 {
+		return true
+	}
+	_, _, ok := CredentialProcessAccount(section)
+	return ok
+}
+
+// Return the context and creation time recorded on a section written by letme. Sections written by older versions
+// of letme do not record them.
+func LetmeManagedMetadata(section *ini.Section) (string, time.Time) {
+	var context string
+	var created time.Time
+	metadata := strings.TrimPrefix(letmeManagedMarker(section), letmeManagedComment)
+	for _, field := range strings.Fields(metadata) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "context":
+			context = value
+		case "created":
+			created, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+	return context, created
+}
+
+// Mark a section of the aws credentials/config files as written by letme, recording the current context and the
+// time letme first wrote it. Comments written by hand above the marker are kept.
+func MarkLetmeManaged(section *ini.Section) {
+	created := time.Now().UTC()
+	if _, previous := LetmeManagedMetadata(section); !previous.IsZero() {
+		created = previous
+	}
+	lines, last := splitSectionComment(section)
+	if len(strings.TrimSpace(last)) > 0 && len(letmeManagedMarker(section)) == 0 {
+		lines = append(lines, last)
+	}
+	lines = append(lines, fmt.Sprintf("%s context=%s created=%s", letmeManagedComment, GetCurrentContext(), created.Format(time.RFC3339)))
+	section.Comment = strings.Join(lines, "\n")
+}
+
+// Take ownership of a section of the aws credentials/config files, dropping the keys it had so nothing written by
+// hand or by another letme mode is left behind
+func ClaimLetmeSection(section *ini.Section) {
+	for _, key := range section.KeyStrings() {
+		section.DeleteKey(key)
+	}
+	MarkLetmeManaged(section)
+}
+
+// Check if a profile exists on the local aws credentials/config files without being managed by letme
+func IsUnmanagedProfile(profileName string) bool {
+	if section, err := AwsCredsFileReadV2().GetSection(profileName); err == nil && !IsLetmeManaged(section) {
+		return true
+	}
+	if section, err := AwsConfigFileReadV2().GetSection("profile " + profileName); err == nil && !IsLetmeManaged(section) {
+		return true
+	}
+	return false
+}
+
+// List the profiles managed by letme which are present on the local aws credentials/config files
+func GetLetmeManagedProfiles() []string {
+	var profiles []string
+	found := make(map[string]bool)
+
+	if _, err := os.Stat(GetHomeDirectory() + "/.aws/credentials"); err == nil {
+		for _, section := range AwsCredsFileReadV2().Sections() {
+			if IsLetmeManaged(section) && !found[section.Name()] {
+				found[section.Name()] = true
+				profiles = append(profiles, section.Name())
+			}
+		}
+	}
+
+	if _, err := os.Stat(GetHomeDirectory() + "/.aws/config"); err == nil {
+		for _, section := range AwsConfigFileReadV2().Sections() {
+			profile := strings.TrimPrefix(section.Name(), "profile ")
+			if IsLetmeManaged(section) && !found[profile] {
+				found[profile] = true
+				profiles = append(profiles, profile)
+			}
+		}
+	}
+
+	sort.Strings(profiles)
+	return profiles
+}
+
 func AwsCredsFileReadV2() *ini.File {
-	awsCredentialsFile, err := ini.Load(GetHomeDirectory() + "/.aws/credentials")
+	content, err := readAwsFile(GetHomeDirectory() + "/.aws/credentials")
+	CheckAndReturnError(err)
+	awsCredentialsFile, err := ini.Load(content)
 	CheckAndReturnError(err)
 	return awsCredentialsFile
 }
 
 func AwsConfigFileReadV2() *ini.File {
-	awsCredentialsFile, err := ini.Load(GetHomeDirectory() + "/.aws/config")
+	content, err := readAwsFile(GetHomeDirectory() + "/.aws/config")
+	CheckAndReturnError(err)
+	awsCredentialsFile, err := ini.Load(content)
 	CheckAndReturnError(err)
 	return awsCredentialsFile
 }
@@ -697,13 +1027,13 @@ func LoadAwsCredentials(profileName string, profileCredential ProfileCredential)
 	credentialsFile := AwsCredsFileReadV2()
 
 	credentialsSection := credentialsFile.Section(profileName)
-	credentialsSection.Comment = "letme managed"
+	ClaimLetmeSection(credentialsSection)
 
 	if err := credentialsSection.ReflectFrom(&profileCredential); err != nil {
 		CheckAndReturnError(err)
 	}
 
-	if err := credentialsFile.SaveTo(GetHomeDirectory() + "/.aws/credentials"); err != nil {
+	if err := SaveAwsFile(credentialsFile, GetHomeDirectory()+"/.aws/credentials"); err != nil {
 		CheckAndReturnError(err)
 	}
 }
@@ -712,11 +1042,11 @@ func LoadAwsConfig(profileName string, profileConfig ProfileConfig) {
 	configFile := AwsConfigFileReadV2()
 
 	configSection := configFile.Section("profile " + profileName)
-	configSection.Comment = "letme managed"
+	ClaimLetmeSection(configSection)
 	if err := configSection.ReflectFrom(&profileConfig); err != nil {
 		CheckAndReturnError(err)
 	}
-	if err := configFile.SaveTo(GetHomeDirectory() + "/.aws/config"); err != nil {
+	if err := SaveAwsFile(configFile, GetHomeDirectory()+"/.aws/config"); err != nil {
 		CheckAndReturnError(err)
 	}
 }
@@ -765,7 +1095,18 @@ func UpdateContext(context string) {
 	}
 }
 
+// Context used instead of the active one, set through UseContext
+var contextOverride string
+
+// Use the given context instead of the active one for the rest of the execution, without switching the active context
+func UseContext(context string) {
+	contextOverride = context
+}
+
 func GetCurrentContext() string {
+	if len(contextOverride) > 0 {
+		return contextOverride
+	}
 	filePath := GetHomeDirectory() + "/.letme/.letme-usersettings"
 
 	//check if the file exists if not exists returns "general"
@@ -827,55 +1168,81 @@ func GetAccount(awsDynamoDbTable string, cfg aws.Config, profileName string) *Dy
 	return account
 }
 
-func GetTableData(awsDynamoDbTable string, tags []string, cfg aws.Config) (resp []DynamoDbAccountConfig) {
-	sesAwsDynamoDb := dynamodb.NewFromConfig(cfg)
+// Return the accounts of a DynamoDB table matching every filter expression, see AccountFilter. The whole table is
+// paged through, the parts of the filters DynamoDB can evaluate are sent along with the scan and the rest is checked
+// on the returned accounts. When attributes are given only those are fetched, which keeps large catalogs fast to list.
+// Contexts with 'dynamodb_tag_index' look the tags every account must have up on the tag index table instead of
+// scanning the catalog, falling back to a scan if that table is missing. Scans use the 'dynamodb_scan_segments' of
+// the context.
+func GetTableData(awsDynamoDbTable string, filters []string, cfg aws.Config, attributes ...string) (resp []DynamoDbAccountConfig) {
 	var accountList []DynamoDbAccountConfig
+	var items []map[string]dynamodbTypes.AttributeValue
 
-	switch {
-	case len(tags) > 0:
-		var filter []string
-		expressionAttributeValues := make(map[string]dynamodbTypes.AttributeValue, len(tags))
-
-		for _, tag := range tags {
-			expressionAttributeValues[":"+tag] = &dynamodbTypes.AttributeValueMemberS{Value: tag}
-			expression := fmt.Sprintf("contains(#tags, :%s)", tag)
-			filter = append(filter, expression)
+	filter, err := ParseAccountFilter(filters)
+	CheckAndReturnError(err)
+	attributes = filterAttributes(attributes)
+	letmeContext := GetContextData(GetCurrentContext())
+
+	if requiredTags := filter.RequiredTags(); len(requiredTags) > 0 && letmeContext.AwsDynamoDbTagIndex {
+		items, err = QueryAccountsByTags(awsDynamoDbTable, requiredTags, cfg, attributes)
+		if isMissingTagIndex(err) {
+			// printed on stderr so json output is kept parseable
+			fmt.Fprintln(os.Stderr, "letme: DynamoDB table '"+awsDynamoDbTable+"' has no tag index table, scanning the whole table. Run 'letme admin reindex-tags' to create and fill '"+TagIndexTable(awsDynamoDbTable)+"'.")
+			items, err = scanFilteredAccounts(awsDynamoDbTable, filter, cfg, attributes, letmeContext.AwsDynamoDbScanSegments)
 		}
+	} else {
+		items, err = scanFilteredAccounts(awsDynamoDbTable, filter, cfg, attributes, letmeContext.AwsDynamoDbScanSegments)
+	}
+	CheckAndReturnError(err)
 
-		filterExpression := strings.Join(filter, " AND ")
-
-		// fmt.Println(expressionAttributeValues, filterExpression)
-
-		resp, err := sesAwsDynamoDb.Scan(context.TODO(), &dynamodb.ScanInput{
-			TableName: aws.String(awsDynamoDbTable),
-			ExpressionAttributeNames: map[string]string{
-				"#tags": "tags",
-			},
-			ExpressionAttributeValues: expressionAttributeValues,
-			FilterExpression:          aws.String(filterExpression),
-		})
+	for _, item := range items {
+		var account DynamoDbAccountConfig
+		err = attributevalue.UnmarshalMap(item, &account)
 		CheckAndReturnError(err)
-		for _, item := range resp.Items {
-			var account DynamoDbAccountConfig
-			err = attributevalue.UnmarshalMap(item, &account)
-			CheckAndReturnError(err)
+		if filter.Match(&account) {
 			accountList = append(accountList, account)
 		}
-	default:
-		resp, err := sesAwsDynamoDb.Scan(context.TODO(), &dynamodb.ScanInput{
-			TableName: aws.String(awsDynamoDbTable),
-		})
-		CheckAndReturnError(err)
+	}
 
-		for _, item := range resp.Items {
-			var account DynamoDbAccountConfig
-			err = attributevalue.UnmarshalMap(item, &account)
-			CheckAndReturnError(err)
-			accountList = append(accountList, account)
+	return accountList
+}
+
+// Add the attributes filters are checked against to a projection, an empty projection fetches every attribute
+func filterAttributes(attributes []string) []string {
+	if len(attributes) == 0 {
+		return attributes
+	}
+	attributes = slices.Clone(attributes)
+	for _, attribute := range []string{"name", "region", "tags"} {
+		if !slices.Contains(attributes, attribute) {
+			attributes = append(attributes, attribute)
 		}
 	}
+	return attributes
+}
 
-	return accountList
+// Scan the accounts of a DynamoDB table, sending the parts of the filter DynamoDB can evaluate. Values are passed as
+// numbered placeholders, since tags such as 'team-a' are not valid placeholder names.
+func scanFilteredAccounts(awsDynamoDbTable string, filter *AccountFilter, cfg aws.Config, attributes []string, segments int) ([]map[string]dynamodbTypes.AttributeValue, error) {
+	input := &dynamodb.ScanInput{
+		TableName:                aws.String(awsDynamoDbTable),
+		ExpressionAttributeNames: make(map[string]string),
+	}
+	projectAttributes(input, attributes)
+
+	expression, names, values := filter.DynamoDbExpression()
+	if len(expression) > 0 {
+		for placeholder, name := range names {
+			input.ExpressionAttributeNames[placeholder] = name
+		}
+		input.ExpressionAttributeValues = values
+		input.FilterExpression = aws.String(expression)
+	}
+	// dynamodb rejects empty expression attribute names
+	if len(input.ExpressionAttributeNames) == 0 {
+		input.ExpressionAttributeNames = nil
+	}
+	return ScanTable(cfg, input, segments)
 }
 
 func ListTextOutput(accountList []DynamoDbAccountConfig) {
@@ -884,15 +1251,15 @@ func ListTextOutput(accountList []DynamoDbAccountConfig) {
 	var w *tabwriter.Writer
 
 	for _, account := range accountList {
-		sorted = append(sorted, account.Name+"\t"+account.Region[0])
+		sorted = append(sorted, account.Name+"\t"+AccountRegion(&account)+"\t"+strings.Join(GetAccountRoleNames(&account), ","))
 		nameLengths = append(nameLengths, len(account.Name))
 	}
 	sort.Ints(nameLengths)
 	sort.Strings(sorted)
 	w = tabwriter.NewWriter(os.Stdout, nameLengths[len(nameLengths)-1]+5, 200, 1, ' ', 0)
 
-	fmt.Fprintln(w, "NAME:\tMAIN REGION:")
-	fmt.Fprintln(w, "-----\t------------")
+	fmt.Fprintln(w, "NAME:\tMAIN REGION:\tROLES:")
+	fmt.Fprintln(w, "-----\t------------\t------")
 	for _, id := range sorted {
 		fmt.Fprintln(w, id)
 		w.Flush()
@@ -904,8 +1271,7 @@ func ListJsonOutput(accountList []DynamoDbAccountConfig) {
 	var accountItems AccountItems
 
 	for _, account := range accountList {
-		accountItems.Items = append(accountItems.Items, AccountItem{Name: account.Name, Region: account.Region[0]})
-		// sorted = append(sorted, account.Name+"\t"+account.Region[0])
+		accountItems.Items = append(accountItems.Items, AccountItem{Name: account.Name, Region: AccountRegion(&account), Roles: GetAccountRoleNames(&account)})
 	}
 
 	sort.Slice(accountItems.Items, func(i, j int) bool {
@@ -946,9 +1312,11 @@ func GetContextData(context string) *LetmeContext {
 }
 
 func AssumeRole(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMfa string, account *DynamoDbAccountConfig, renew bool, localCredentialProcessFlagV1 bool, authMethod string) (ProfileCredential, ProfileConfig) {
+	profileName := GetProfileName(account)
+
 	//if credentials not expired
-	if CheckAccountAvailability(account.Name) && !localCredentialProcessFlagV1 && !renew {
-		cachedCredentials := ReturnAccountCredentials(account.Name)
+	if CheckAccountAvailability(profileName) && !localCredentialProcessFlagV1 && !renew && CachedSessionPolicyMatches(account) {
+		cachedCredentials := ReturnAccountCredentials(profileName)
 		profileCredential := ProfileCredential{
 			AccessKey:    cachedCredentials["AccessKeyId"],
 			SecretKey:    cachedCredentials["SecretAccessKey"],
@@ -957,46 +1325,17 @@ func AssumeRole(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMfa strin
 
 		profileConfig := ProfileConfig{
 			Output: "json",
-			Region: account.Region[0],
+			Region: AccountRegion(account),
 		}
 
 		fmt.Println("letme: using cached credentials. Append argument '--renew' to obtain new credentials.")
 		return profileCredential, profileConfig
 	}
 
-	sesAwsSts := sts.NewFromConfig(cfg)
-	var input *sts.AssumeRoleInput
+	sesAwsSts := hopStsClient(cfg, account.Role[0])
+	input := assumeRoleHopInput(letmeContext, account.Role[0], GetAssumeRoleOptions(letmeContext, account, cfg), true, true, &inlineTokenMfa)
 
-	switch {
-	case len(letmeContext.AwsMfaArn) > 0 && len(inlineTokenMfa) > 0:
-		input = &sts.AssumeRoleInput{
-			RoleArn:         &account.Role[0],
-			RoleSessionName: &letmeContext.AwsSessionName,
-			SerialNumber:    &letmeContext.AwsMfaArn,
-			TokenCode:       &inlineTokenMfa,
-			DurationSeconds: &letmeContext.AwsSessionDuration,
-		}
-	case len(letmeContext.AwsMfaArn) > 0 && len(inlineTokenMfa) <= 0:
-		fmt.Printf("Enter MFA one time pass code: ")
-		var tokenMfa string
-		fmt.Scanln(&tokenMfa)
-		input = &sts.AssumeRoleInput{
-			RoleArn:         &account.Role[0],
-			RoleSessionName: &letmeContext.AwsSessionName,
-			SerialNumber:    &letmeContext.AwsMfaArn,
-			TokenCode:       &tokenMfa,
-			DurationSeconds: &letmeContext.AwsSessionDuration,
-		}
-	default:
-		input = &sts.AssumeRoleInput{
-			RoleArn:         &account.Role[0],
-			RoleSessionName: &letmeContext.AwsSessionName,
-			DurationSeconds: &letmeContext.AwsSessionDuration,
-		}
-	}
-
-	resp, err := sesAwsSts.AssumeRole(context.TODO(), input)
-	CheckAndReturnError(err)
+	resp := assumeRoleNegotiatingDuration(sesAwsSts, input, false, localCredentialProcessFlagV1)
 
 	profileCredential := ProfileCredential{
 		AccessKey:    *resp.Credentials.AccessKeyId,
@@ -1006,23 +1345,25 @@ func AssumeRole(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMfa strin
 
 	profileConfig := ProfileConfig{
 		Output: "json",
-		Region: account.Region[0],
+		Region: AccountRegion(account),
 	}
 	switch {
 	case localCredentialProcessFlagV1:
 		fmt.Printf(CredentialsProcessOutput(profileCredential.AccessKey, profileCredential.SecretKey, profileCredential.SessionToken, *resp.Credentials.Expiration))
 		os.Exit(0)
-	case renew || !CheckAccountAvailability(account.Name):
-		DatabaseFile(account.Name, letmeContext.AwsSessionDuration, CredentialsProcessOutput(profileCredential.AccessKey, profileCredential.SecretKey, profileCredential.SessionToken, *resp.Credentials.Expiration), authMethod) //only when we really authenticate against aws
+	case renew || !CheckAccountAvailability(profileName) || !CachedSessionPolicyMatches(account):
+		DatabaseFile(profileName, *resp.Credentials.Expiration, CredentialsProcessOutput(profileCredential.AccessKey, profileCredential.SecretKey, profileCredential.SessionToken, *resp.Credentials.Expiration), authMethod, SessionPolicyDigest(account)) //only when we really authenticate against aws
 	}
 
 	return profileCredential, profileConfig
 }
 
 func AssumeRoleChained(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMfa string, account *DynamoDbAccountConfig, renew bool, localCredentialProcessFlagV1 bool, authMethod string) (ProfileCredential, ProfileConfig) {
+	profileName := GetProfileName(account)
+
 	//if credentials not expired
-	if CheckAccountAvailability(account.Name) && !localCredentialProcessFlagV1 {
-		cachedCredentials := ReturnAccountCredentials(account.Name)
+	if CheckAccountAvailability(profileName) && !localCredentialProcessFlagV1 && CachedSessionPolicyMatches(account) {
+		cachedCredentials := ReturnAccountCredentials(profileName)
 		profileCredential := ProfileCredential{
 			AccessKey:    cachedCredentials["AccessKeyId"],
 			SecretKey:    cachedCredentials["SecretAccessKey"],
@@ -1031,60 +1372,33 @@ func AssumeRoleChained(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMf
 
 		profileConfig := ProfileConfig{
 			Output: "json",
-			Region: account.Region[0],
+			Region: AccountRegion(account),
 		}
 
 		fmt.Println("letme: using cached credentials. Append argument '--renew' to obtain new credentials.")
 		return profileCredential, profileConfig
 	}
 
-	sesAwsSts := sts.NewFromConfig(cfg)
 	var input *sts.AssumeRoleInput
 	var output *sts.AssumeRoleOutput
-	var err error
+	assumeRoleOptions := GetAssumeRoleOptions(letmeContext, account, cfg)
 
 	fmt.Println("More than one role detected. Total hops:", len(account.Role))
-	for i := range account.Role {
+	for i, hop := range account.Role {
 		fmt.Printf("[%v/%v]\n", i+1, len(account.Role))
+		input = assumeRoleHopInput(letmeContext, hop, assumeRoleOptions, i == 0, i == len(account.Role)-1, &inlineTokenMfa)
 		switch {
-		//first hop with --inline-mfa flag
-		case i == 0 && len(letmeContext.AwsMfaArn) > 0 && len(inlineTokenMfa) > 0:
-			input = &sts.AssumeRoleInput{
-				RoleArn:         &account.Role[i],
-				RoleSessionName: &letmeContext.AwsSessionName,
-				SerialNumber:    &letmeContext.AwsMfaArn,
-				TokenCode:       &inlineTokenMfa,
-				DurationSeconds: &letmeContext.AwsSessionDuration,
-			}
-			output, err = sesAwsSts.AssumeRole(context.TODO(), input)
-			CheckAndReturnError(err)
-		//first hop with interactive MFA token
-		case i == 0 && len(letmeContext.AwsMfaArn) > 0 && len(inlineTokenMfa) <= 0:
-			var tokenMfa string
-			fmt.Printf("Enter MFA one time pass code: ")
-			fmt.Scanln(&tokenMfa)
-			input = &sts.AssumeRoleInput{
-				RoleArn:         &account.Role[i],
-				RoleSessionName: &letmeContext.AwsSessionName,
-				SerialNumber:    &letmeContext.AwsMfaArn,
-				TokenCode:       &tokenMfa,
-				DurationSeconds: &letmeContext.AwsSessionDuration,
-			}
-			output, err = sesAwsSts.AssumeRole(context.TODO(), input)
-			CheckAndReturnError(err)
-		//first hop without MFA
+		//first hop with the source profile credentials
 		case i == 0:
-			input = &sts.AssumeRoleInput{
-				RoleArn:         &account.Role[i],
-				RoleSessionName: &letmeContext.AwsSessionName,
-				DurationSeconds: &letmeContext.AwsSessionDuration,
-			}
-			output, err = sesAwsSts.AssumeRole(context.TODO(), input)
-			CheckAndReturnError(err)
+			output = assumeRoleNegotiatingDuration(hopStsClient(cfg, hop), input, false, localCredentialProcessFlagV1)
 		//chained AssumneRoles with credentials from previous iterations
 		default:
+			region := AccountRegion(account)
+			if len(hop.Region) > 0 {
+				region = hop.Region
+			}
 			cfg, err := config.LoadDefaultConfig(context.TODO(),
-				config.WithRegion(account.Region[0]),
+				config.WithRegion(region),
 				config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
 					Value: aws.Credentials{
 						AccessKeyID: *output.Credentials.AccessKeyId, SecretAccessKey: *output.Credentials.SecretAccessKey, SessionToken: *output.Credentials.SessionToken,
@@ -1092,13 +1406,7 @@ func AssumeRoleChained(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMf
 				}))
 			CheckAndReturnError(err)
 			sesChainedSts := sts.NewFromConfig(cfg)
-			input = &sts.AssumeRoleInput{
-				RoleArn:         &account.Role[i],
-				RoleSessionName: &letmeContext.AwsSessionName,
-				DurationSeconds: &letmeContext.AwsSessionDuration,
-			}
-			output, err = sesChainedSts.AssumeRole(context.TODO(), input)
-			CheckAndReturnError(err)
+			output = assumeRoleNegotiatingDuration(sesChainedSts, input, true, localCredentialProcessFlagV1)
 		}
 	}
 
@@ -1110,19 +1418,115 @@ func AssumeRoleChained(letmeContext *LetmeContext, cfg aws.Config, inlineTokenMf
 
 	profileConfig := ProfileConfig{
 		Output: "json",
-		Region: account.Region[0],
+		Region: AccountRegion(account),
 	}
 
 	switch {
 	case localCredentialProcessFlagV1:
 		fmt.Printf(CredentialsProcessOutput(profileCredential.AccessKey, profileCredential.SecretKey, profileCredential.SessionToken, *output.Credentials.Expiration))
 		os.Exit(0)
-	case renew || !CheckAccountAvailability(account.Name):
-		DatabaseFile(account.Name, letmeContext.AwsSessionDuration, CredentialsProcessOutput(profileCredential.AccessKey, profileCredential.SecretKey, profileCredential.SessionToken, *output.Credentials.Expiration), authMethod) //only when we really authenticate against aws
+	case renew || !CheckAccountAvailability(profileName) || !CachedSessionPolicyMatches(account):
+		DatabaseFile(profileName, *output.Credentials.Expiration, CredentialsProcessOutput(profileCredential.AccessKey, profileCredential.SecretKey, profileCredential.SessionToken, *output.Credentials.Expiration), authMethod, SessionPolicyDigest(account)) //only when we really authenticate against aws
 	}
 	return profileCredential, profileConfig
 }
 
+// Build the AssumeRole input of a hop in the role chain, the hop settings take precedence over the context ones.
+// The first hop asks for an MFA token when the context has an MFA device, following hops only when the hop requires it.
+// The inline MFA token is consumed by the first hop that needs one, the user is prompted for the rest.
+func assumeRoleHopInput(letmeContext *LetmeContext, hop RoleHop, options AssumeRoleOptions, firstHop bool, lastHop bool, inlineTokenMfa *string) *sts.AssumeRoleInput {
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(hop.Arn),
+		RoleSessionName: aws.String(letmeContext.AwsSessionName),
+		DurationSeconds: aws.Int32(letmeContext.AwsSessionDuration),
+	}
+	if len(hop.SessionName) > 0 {
+		input.RoleSessionName = aws.String(hop.SessionName)
+	}
+	if hop.SessionDuration > 0 {
+		input.DurationSeconds = aws.Int32(hop.SessionDuration)
+	}
+
+	requiresMfa := firstHop && len(letmeContext.AwsMfaArn) > 0
+	if hop.Mfa != nil {
+		requiresMfa = *hop.Mfa
+	}
+	if requiresMfa {
+		if len(letmeContext.AwsMfaArn) == 0 {
+			CheckAndReturnError(fmt.Errorf("letme: role '%s' requires MFA but the context has no 'mfa_arn' configured", hop.Arn))
+		}
+		tokenMfa := *inlineTokenMfa
+		if len(tokenMfa) == 0 && DryRun {
+			tokenMfa = "000000"
+		} else if len(tokenMfa) == 0 {
+			fmt.Printf("Enter MFA one time pass code: ")
+			fmt.Scanln(&tokenMfa)
+		}
+		*inlineTokenMfa = ""
+		input.SerialNumber = aws.String(letmeContext.AwsMfaArn)
+		input.TokenCode = aws.String(tokenMfa)
+	}
+
+	applyAssumeRoleOptions(input, options, firstHop, lastHop)
+	if len(hop.ExternalId) > 0 {
+		input.ExternalId = aws.String(hop.ExternalId)
+	}
+	return input
+}
+
+// Maximum session duration sts grants to roles assumed through role chaining
+const ChainedSessionDuration = 3600
+
+// Call AssumeRole negotiating the session duration. Hops assumed through role chaining are capped at one hour, and
+// roles whose MaxSessionDuration is lower than requested are retried with the largest whole hour they allow. The user
+// is told what was granted whenever it differs from the request, on stderr when stdout holds credential_process output.
+func assumeRoleNegotiatingDuration(sesAwsSts *sts.Client, input *sts.AssumeRoleInput, chained bool, localCredentialProcessFlagV1 bool) *sts.AssumeRoleOutput {
+	requestedDuration := *input.DurationSeconds
+	if chained && *input.DurationSeconds > ChainedSessionDuration {
+		input.DurationSeconds = aws.Int32(ChainedSessionDuration)
+	}
+	if DryRun {
+		return dryRunAssumeRole(input)
+	}
+
+	output, err := sesAwsSts.AssumeRole(context.TODO(), input)
+	for isDurationError(err) && *input.DurationSeconds > ChainedSessionDuration {
+		// MaxSessionDuration is always a value between one and twelve hours
+		input.DurationSeconds = aws.Int32(max((*input.DurationSeconds-1)/3600*3600, ChainedSessionDuration))
+		output, err = sesAwsSts.AssumeRole(context.TODO(), input)
+	}
+	CheckAndReturnError(err)
+
+	if *input.DurationSeconds != requestedDuration {
+		reason := "does not allow"
+		if chained {
+			reason = "is assumed through role chaining, which does not allow"
+		}
+		message := fmt.Sprintf("letme: role '%s' %s a %vs session, granted %vs instead. Credentials expire at %s.\n", *input.RoleArn, reason, requestedDuration, *input.DurationSeconds, output.Credentials.Expiration.Local().Format(time.RFC1123))
+		if localCredentialProcessFlagV1 {
+			fmt.Fprint(os.Stderr, message)
+		} else {
+			fmt.Print(message)
+		}
+	}
+	return output
+}
+
+// Check if sts rejected the requested session duration
+func isDurationError(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationError" && strings.Contains(apiErr.ErrorMessage(), "DurationSeconds")
+}
+
+// Create the sts client for the first hop of the role chain, using the hop sts region if set
+func hopStsClient(cfg aws.Config, hop RoleHop) *sts.Client {
+	return sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if len(hop.Region) > 0 {
+			o.Region = hop.Region
+		}
+	})
+}
+
 // Check if letme-config file exists and if its valid
 func ConfigFileHealth() {
 	if _, err := os.Stat(GetHomeDirectory() + "/.letme/letme-config"); err == nil {
@@ -1137,3 +1541,600 @@ func ConfigFileHealth() {
 		os.Exit(1)
 	}
 }
+
+// Build an aws config which authenticates with the given profile credentials
+func AwsConfigFromProfileCredential(profileCredential ProfileCredential, region string) aws.Config {
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion(region),
+		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
+			Value: aws.Credentials{
+				AccessKeyID: profileCredential.AccessKey, SecretAccessKey: profileCredential.SecretKey, SessionToken: profileCredential.SessionToken,
+			},
+		}))
+	CheckAndReturnError(err)
+	return cfg
+}
+
+// Return the expiry time stored in the database file for an account, false if the account is not stored
+func GetAccountExpiry(accountName string) (time.Time, bool) {
+	for _, ident := range ReadDatabaseFile() {
+		if ident.Account.Name == accountName {
+			return time.Unix(ident.Account.Expiry, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Call sts GetCallerIdentity and print the identity behind the credentials. If accountName is stored in the
+// database file, the remaining lifetime of its credentials is also printed.
+func PrintCallerIdentity(cfg aws.Config, accountName string) {
+	sesAwsSts := sts.NewFromConfig(cfg)
+	resp, err := sesAwsSts.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+	CheckAndReturnError(err)
+
+	// assumed role arns follow the arn:aws:sts::$ACCOUNT:assumed-role/$ROLE/$SESSION_NAME format
+	sessionName := "-"
+	if arnParts := strings.Split(*resp.Arn, "/"); strings.Contains(*resp.Arn, ":assumed-role/") && len(arnParts) == 3 {
+		sessionName = arnParts[2]
+	}
+
+	remainingLifetime := "unknown"
+	if expiry, ok := GetAccountExpiry(accountName); ok && len(accountName) > 0 {
+		remaining := time.Until(expiry).Round(time.Second)
+		if remaining <= 0 {
+			remainingLifetime = "expired"
+		} else {
+			remainingLifetime = remaining.String() + " (expires " + expiry.Format(time.RFC1123) + ")"
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "ARN:\t"+*resp.Arn)
+	fmt.Fprintln(w, "ACCOUNT ID:\t"+*resp.Account)
+	fmt.Fprintln(w, "SESSION NAME:\t"+sessionName)
+	fmt.Fprintln(w, "REMAINING LIFETIME:\t"+remainingLifetime)
+	w.Flush()
+}
+
+// Struct which states how often an account is obtained and if the user marked it as favorite
+type AccountUsage struct {
+	Name     string `json:"name"`
+	Context  string `json:"context"`
+	Count    int64  `json:"count"`
+	LastUsed int64  `json:"lastUsed"`
+	Favorite bool   `json:"favorite,omitempty"`
+}
+
+// Read the usage file which tracks obtained and favorite accounts, a missing or empty file returns no entries
+func ReadUsageFile() []AccountUsage {
+	var usage []AccountUsage
+	usageFileReader, err := os.ReadFile(GetHomeDirectory() + "/.letme/.letme-usage")
+	if os.IsNotExist(err) {
+		return usage
+	}
+	CheckAndReturnError(err)
+	if len(usageFileReader) == 0 {
+		return usage
+	}
+	if !json.Valid(usageFileReader) {
+		fmt.Printf("letme: " + GetHomeDirectory() + "/.letme/.letme-usage" + " is not JSON valid. Remove the file and try again.\n")
+		os.Exit(1)
+	}
+	err = json.Unmarshal(usageFileReader, &usage)
+	CheckAndReturnError(err)
+	return usage
+}
+
+// Overwrite the usage file with the given entries
+func WriteUsageFile(usage []AccountUsage) {
+	if DryRun {
+		return
+	}
+	if usage == nil {
+		usage = []AccountUsage{}
+	}
+	b, err := json.MarshalIndent(usage, "", "  ")
+	CheckAndReturnError(err)
+	if err := os.WriteFile(GetHomeDirectory()+"/.letme/.letme-usage", b, 0600); err != nil {
+		CheckAndReturnError(err)
+	}
+}
+
+// Return the usage entries of a context, most recently used first
+func GetAccountUsage(context string) []AccountUsage {
+	var contextUsage []AccountUsage
+	for _, entry := range ReadUsageFile() {
+		if entry.Context == context {
+			contextUsage = append(contextUsage, entry)
+		}
+	}
+	sort.SliceStable(contextUsage, func(i, j int) bool {
+		return contextUsage[i].LastUsed > contextUsage[j].LastUsed
+	})
+	return contextUsage
+}
+
+// Increase the times an account has been obtained within the current context
+func RecordAccountUsage(accountName string) {
+	context := GetCurrentContext()
+	usage := ReadUsageFile()
+	for i := range usage {
+		if usage[i].Name == accountName && usage[i].Context == context {
+			usage[i].Count++
+			usage[i].LastUsed = time.Now().Unix()
+			WriteUsageFile(usage)
+			return
+		}
+	}
+	usage = append(usage, AccountUsage{Name: accountName, Context: context, Count: 1, LastUsed: time.Now().Unix()})
+	WriteUsageFile(usage)
+}
+
+// Mark or unmark an account as favorite within the current context, returns false if nothing changed
+func SetFavoriteAccount(accountName string, favorite bool) bool {
+	context := GetCurrentContext()
+	usage := ReadUsageFile()
+	for i := range usage {
+		if usage[i].Name == accountName && usage[i].Context == context {
+			if usage[i].Favorite == favorite {
+				return false
+			}
+			usage[i].Favorite = favorite
+			WriteUsageFile(usage)
+			return true
+		}
+	}
+	if !favorite {
+		return false
+	}
+	usage = append(usage, AccountUsage{Name: accountName, Context: context, Favorite: true})
+	WriteUsageFile(usage)
+	return true
+}
+
+// Return the favorite account names of a context
+func GetFavoriteAccounts(context string) []string {
+	var favorites []string
+	for _, entry := range GetAccountUsage(context) {
+		if entry.Favorite {
+			favorites = append(favorites, entry.Name)
+		}
+	}
+	sort.Strings(favorites)
+	return favorites
+}
+
+// Struct which states the account names of a context cached for shell completion
+type CompletionCache struct {
+	Context  string   `json:"context"`
+	Updated  int64    `json:"updated"`
+	Accounts []string `json:"accounts"`
+}
+
+// Time after which the cached account names are refreshed from DynamoDB
+const CompletionCacheTtl = 24 * time.Hour
+
+// Return the cached account names of a context and whether they are still fresh. Errors are ignored
+// since the cache is only used for shell completion.
+func GetCompletionCache(context string) ([]string, bool) {
+	var cache []CompletionCache
+	cacheFileReader, err := os.ReadFile(GetHomeDirectory() + "/.letme/.letme-completion-cache")
+	if err != nil || json.Unmarshal(cacheFileReader, &cache) != nil {
+		return nil, false
+	}
+	for _, entry := range cache {
+		if entry.Context == context {
+			return entry.Accounts, time.Since(time.Unix(entry.Updated, 0)) < CompletionCacheTtl
+		}
+	}
+	return nil, false
+}
+
+// Store the account names of a context for shell completion
+func UpdateCompletionCache(context string, accountList []DynamoDbAccountConfig) {
+	var cache []CompletionCache
+	if cacheFileReader, err := os.ReadFile(GetHomeDirectory() + "/.letme/.letme-completion-cache"); err == nil {
+		json.Unmarshal(cacheFileReader, &cache)
+	}
+
+	accounts := make([]string, 0, len(accountList))
+	for _, account := range accountList {
+		accounts = append(accounts, account.Name)
+	}
+	sort.Strings(accounts)
+
+	updated := false
+	for i := range cache {
+		if cache[i].Context == context {
+			cache[i].Accounts = accounts
+			cache[i].Updated = time.Now().Unix()
+			updated = true
+		}
+	}
+	if !updated {
+		cache = append(cache, CompletionCache{Context: context, Updated: time.Now().Unix(), Accounts: accounts})
+	}
+
+	b, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(GetHomeDirectory()+"/.letme/.letme-completion-cache", b, 0600)
+}
+
+// Scan the account names, regions and tags of a DynamoDB table without exiting on errors, so it can be used
+// while completing shell arguments. Only accounts matching every filter expression are returned. The table is
+// scanned in the given number of segments, see ScanTable.
+func ScanAccountNames(awsDynamoDbTable string, filters []string, cfg aws.Config, segments int) ([]DynamoDbAccountConfig, error) {
+	var accountList []DynamoDbAccountConfig
+
+	filter, err := ParseAccountFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanFilteredAccounts(awsDynamoDbTable, filter, cfg, []string{"name", "region", "tags"}, segments)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var account DynamoDbAccountConfig
+		if err := attributevalue.UnmarshalMap(item, &account); err != nil {
+			return nil, err
+		}
+		if filter.Match(&account) {
+			accountList = append(accountList, account)
+		}
+	}
+	return accountList, nil
+}
+
+// Merge the optional AssumeRole parameters of a context with the ones of an account item, the account item takes
+// precedence. A source identity set to 'iam_user' is resolved to the IAM user name of the source profile.
+func GetAssumeRoleOptions(letmeContext *LetmeContext, account *DynamoDbAccountConfig, cfg aws.Config) AssumeRoleOptions {
+	options := AssumeRoleOptions{
+		ExternalId:     letmeContext.AwsExternalId,
+		SourceIdentity: letmeContext.AwsSourceIdentity,
+		SessionTags:    make(map[string]string),
+	}
+
+	for _, tag := range letmeContext.AwsSessionTags {
+		key, value, found := strings.Cut(tag, "=")
+		if !found || len(key) == 0 {
+			CheckAndReturnError(fmt.Errorf("letme: session tag '%s' does not follow the 'key=value' format", tag))
+		}
+		options.SessionTags[key] = value
+	}
+	for key, value := range account.SessionTags {
+		options.SessionTags[key] = value
+	}
+
+	options.TransitiveTagKeys = append(options.TransitiveTagKeys, letmeContext.AwsTransitiveTagKeys...)
+	for _, key := range account.TransitiveTagKeys {
+		if !slices.Contains(options.TransitiveTagKeys, key) {
+			options.TransitiveTagKeys = append(options.TransitiveTagKeys, key)
+		}
+	}
+
+	if len(account.ExternalId) > 0 {
+		options.ExternalId = account.ExternalId
+	}
+	if len(account.SourceIdentity) > 0 {
+		options.SourceIdentity = account.SourceIdentity
+	}
+	options.Policy = account.SessionPolicy
+	options.PolicyArns = account.SessionPolicyArns
+
+	// the iam user is only resolved when credentials are really requested
+	if options.SourceIdentity == "iam_user" && !DryRun {
+		sesAwsSts := sts.NewFromConfig(cfg)
+		resp, err := sesAwsSts.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+		CheckAndReturnError(err)
+		// iam user arns follow the arn:aws:iam::$ACCOUNT:user/$PATH/$USER_NAME format
+		if !strings.Contains(*resp.Arn, ":user/") {
+			CheckAndReturnError(fmt.Errorf("letme: source identity 'iam_user' requires the source profile to be an IAM user, got '%s'", *resp.Arn))
+		}
+		options.SourceIdentity = (*resp.Arn)[strings.LastIndex(*resp.Arn, "/")+1:]
+	}
+
+	return options
+}
+
+// Set the optional AssumeRole parameters on the input. Source identity and session tags are only set on the first
+// hop of a role chain since sts keeps them for the following hops, the external id is sent on every hop and session
+// policies only scope down the last hop.
+func applyAssumeRoleOptions(input *sts.AssumeRoleInput, options AssumeRoleOptions, firstHop bool, lastHop bool) {
+	if len(options.ExternalId) > 0 {
+		input.ExternalId = aws.String(options.ExternalId)
+	}
+	if lastHop && len(options.Policy) > 0 {
+		input.Policy = aws.String(options.Policy)
+	}
+	if lastHop {
+		for _, policyArn := range options.PolicyArns {
+			input.PolicyArns = append(input.PolicyArns, stsTypes.PolicyDescriptorType{Arn: aws.String(policyArn)})
+		}
+	}
+	if !firstHop {
+		return
+	}
+	if len(options.SourceIdentity) > 0 {
+		input.SourceIdentity = aws.String(options.SourceIdentity)
+	}
+
+	keys := make([]string, 0, len(options.SessionTags))
+	for key := range options.SessionTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		input.Tags = append(input.Tags, stsTypes.Tag{Key: aws.String(key), Value: aws.String(options.SessionTags[key])})
+	}
+	if len(options.TransitiveTagKeys) > 0 {
+		input.TransitiveTagKeys = options.TransitiveTagKeys
+	}
+}
+
+// Return a digest of the session policies scoping down an account, empty if the account has none
+func SessionPolicyDigest(account *DynamoDbAccountConfig) string {
+	if len(account.SessionPolicy) == 0 && len(account.SessionPolicyArns) == 0 {
+		return ""
+	}
+	digest := sha256.Sum256([]byte(account.SessionPolicy + "\n" + strings.Join(account.SessionPolicyArns, ",")))
+	return hex.EncodeToString(digest[:])
+}
+
+// Check if the cached credentials of an account were obtained with the same session policies, so credentials
+// scoped down for an investigation are not reused by a regular obtain and vice versa
+func CachedSessionPolicyMatches(account *DynamoDbAccountConfig) bool {
+	for _, ident := range ReadDatabaseFile() {
+		if ident.Account.Name == GetProfileName(account) {
+			return ident.Account.SessionPolicy == SessionPolicyDigest(account)
+		}
+	}
+	return false
+}
+
+// Read and validate a json policy document used as inline session policy
+func ReadSessionPolicyFile(path string) string {
+	policy, err := os.ReadFile(path)
+	CheckAndReturnError(err)
+	// whitespace counts towards the packed policy size limit of sts
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, policy); err != nil {
+		CheckAndReturnError(fmt.Errorf("letme: session policy file '%s' is not JSON valid", path))
+	}
+	return compacted.String()
+}
+
+// Return the named role sets of an account sorted by name
+func GetAccountRoleNames(account *DynamoDbAccountConfig) []string {
+	roleNames := make([]string, 0, len(account.Roles))
+	for roleName := range account.Roles {
+		roleNames = append(roleNames, roleName)
+	}
+	sort.Strings(roleNames)
+	return roleNames
+}
+
+// Pick the role chain to assume from the named role sets of an account. Without a role name, the account default
+// role is used, then the context default role. Accounts with a plain role chain keep using it when no role is given.
+func SelectAccountRole(account *DynamoDbAccountConfig, roleName string, contextDefaultRole string) {
+	if len(account.Roles) == 0 {
+		if len(roleName) > 0 {
+			CheckAndReturnError(fmt.Errorf("letme: account '%s' does not define named roles, cannot use role '%s'", account.Name, roleName))
+		}
+		return
+	}
+
+	switch {
+	case len(roleName) > 0:
+	case len(account.DefaultRole) > 0:
+		roleName = account.DefaultRole
+	case len(account.Roles[contextDefaultRole]) > 0:
+		roleName = contextDefaultRole
+	case len(account.Role) > 0:
+		return
+	case len(account.Roles) == 1:
+		roleName = GetAccountRoleNames(account)[0]
+	default:
+		CheckAndReturnError(fmt.Errorf("letme: account '%s' has several roles (%s), choose one with '--role'", account.Name, strings.Join(GetAccountRoleNames(account), ", ")))
+	}
+
+	roleChain, ok := account.Roles[roleName]
+	if !ok {
+		CheckAndReturnError(fmt.Errorf("letme: account '%s' does not have a role named '%s', available roles: %s", account.Name, roleName, strings.Join(GetAccountRoleNames(account), ", ")))
+	}
+	account.Role = roleChain
+	account.SelectedRole = roleName
+}
+
+// Profile name template used by contexts without a 'profile_template' key
+const DefaultProfileTemplate = "{account}-{role}"
+
+// Placeholders supported by profile name templates
+var profileTemplatePlaceholders = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// Return the name of the local aws profile for an account, rendered with the profile template of the current context
+func GetProfileName(account *DynamoDbAccountConfig) string {
+	return RenderProfileName(GetProfileTemplate(), GetCurrentContext(), account)
+}
+
+// Return the profile template of the current context, or the default one if the context does not set it or
+// letme has not been configured yet
+func GetProfileTemplate() string {
+	if _, err := os.Stat(GetHomeDirectory() + "/.letme/letme-config"); err != nil {
+		return DefaultProfileTemplate
+	}
+	currentContext := GetCurrentContext()
+	if !slices.Contains(GetAvalaibleContexts(), currentContext) {
+		return DefaultProfileTemplate
+	}
+	if profileTemplate := GetContextData(currentContext).AwsProfileTemplate; len(profileTemplate) > 0 {
+		return profileTemplate
+	}
+	return DefaultProfileTemplate
+}
+
+// Render a profile name template such as '{context}-{account}-{role}-{region}'. Placeholders without a value are
+// dropped along with the separator before them. Per-region profiles are suffixed with the region when the template
+// does not use '{region}', which otherwise falls back to the main region of the account.
+func RenderProfileName(profileTemplate string, context string, account *DynamoDbAccountConfig) string {
+	region := account.SelectedRegion
+	if len(region) == 0 && len(account.Region) > 0 {
+		region = account.Region[0]
+	}
+	values := map[string]string{
+		"context": context,
+		"account": account.Name,
+		"role":    account.SelectedRole,
+		"region":  region,
+	}
+
+	var profileName strings.Builder
+	dropSeparator := false
+	last := 0
+	for _, match := range profileTemplatePlaceholders.FindAllStringSubmatchIndex(profileTemplate, -1) {
+		literal := profileTemplate[last:match[0]]
+		last = match[1]
+		placeholder := profileTemplate[match[2]:match[3]]
+		value, ok := values[placeholder]
+		if !ok {
+			CheckAndReturnError(fmt.Errorf("letme: unknown placeholder '{%s}' in profile template '%s'", placeholder, profileTemplate))
+		}
+		switch {
+		// drop the separator before an empty placeholder, or after it when nothing was rendered yet
+		case len(value) == 0 && profileName.Len() == 0:
+			profileName.WriteString(literal)
+			dropSeparator = true
+		case len(value) == 0:
+		case dropSeparator:
+			profileName.WriteString(value)
+			dropSeparator = false
+		default:
+			profileName.WriteString(literal + value)
+		}
+	}
+	profileName.WriteString(profileTemplate[last:])
+
+	if len(account.SelectedRegion) > 0 && !strings.Contains(profileTemplate, "{region}") {
+		profileName.WriteString("-" + account.SelectedRegion)
+	}
+	return profileName.String()
+}
+
+// Check if a profile template is valid, it must reference the account and only use known placeholders
+func ValidateProfileTemplate(profileTemplate string) error {
+	if !strings.Contains(profileTemplate, "{account}") {
+		return fmt.Errorf("letme: profile template '%s' must contain the '{account}' placeholder", profileTemplate)
+	}
+	for _, match := range profileTemplatePlaceholders.FindAllStringSubmatch(profileTemplate, -1) {
+		switch match[1] {
+		case "context", "account", "role", "region":
+		default:
+			return fmt.Errorf("letme: unknown placeholder '{%s}' in profile template '%s'", match[1], profileTemplate)
+		}
+	}
+	return nil
+}
+
+// Region used by accounts without regions on the catalog
+const DefaultAccountRegion = "us-east-1"
+
+// Return the main region of an account, the first one on the catalog or DefaultAccountRegion if it has none
+func AccountRegion(account *DynamoDbAccountConfig) string {
+	if len(account.Region) == 0 {
+		return DefaultAccountRegion
+	}
+	return account.Region[0]
+}
+
+// Make the given region the main region of an account, so it is used by the profile and the chained sts calls. The
+// region must be listed by the account, accounts without regions default to 'us-east-1'.
+func SelectAccountRegion(account *DynamoDbAccountConfig, region string) {
+	if len(account.Region) == 0 {
+		account.Region = []string{DefaultAccountRegion}
+	}
+	if len(region) == 0 {
+		return
+	}
+
+	index := slices.Index(account.Region, region)
+	if index == -1 {
+		CheckAndReturnError(fmt.Errorf("letme: region '%s' is not configured for account '%s', available regions: %s", region, account.Name, strings.Join(account.Region, ", ")))
+	}
+	account.Region = append([]string{region}, slices.Delete(slices.Clone(account.Region), index, index+1)...)
+}
+
+// Write aws cli profiles for an account which let the aws cli assume the role chain itself through 'role_arn' and
+// 'source_profile', so no credentials are written to disk. Every hop but the last one is written as an intermediate
+// profile suffixed with its hop number. Accounts whose profiles already exist and are not managed by letme are
+// skipped. Returns the profile names written to the config file.
+func AwsConfigFileNativeProfiles(configFile *ini.File, letmeContext *LetmeContext, account *DynamoDbAccountConfig) []string {
+	profileName := GetProfileName(account)
+	hopProfileNames := make([]string, len(account.Role))
+	for i := range account.Role {
+		hopProfileNames[i] = profileName
+		if i < len(account.Role)-1 {
+			hopProfileNames[i] = fmt.Sprintf("%s-hop-%v", profileName, i+1)
+		}
+		if section, err := configFile.GetSection("profile " + hopProfileNames[i]); err == nil && !IsLetmeManaged(section) {
+			fmt.Println("letme: profile '" + hopProfileNames[i] + "' is not managed by letme, skipping account '" + account.Name + "'.")
+			return nil
+		}
+	}
+	if len(letmeContext.AwsSourceIdentity) > 0 || len(account.SourceIdentity) > 0 || len(letmeContext.AwsSessionTags) > 0 || len(account.SessionTags) > 0 || len(account.SessionPolicy) > 0 || len(account.SessionPolicyArns) > 0 {
+		fmt.Println("letme: source identity, session tags and session policies are not supported by native profiles, ignoring them for '" + profileName + "'.")
+	}
+
+	sourceProfile := letmeContext.AwsSourceProfile
+	for i, hop := range account.Role {
+		section := configFile.Section("profile " + hopProfileNames[i])
+		ClaimLetmeSection(section)
+
+		// chained sessions are capped to one hour by aws
+		duration := letmeContext.AwsSessionDuration
+		if hop.SessionDuration > 0 {
+			duration = hop.SessionDuration
+		}
+		if i > 0 && duration > ChainedSessionDuration {
+			duration = ChainedSessionDuration
+		}
+		sessionName := letmeContext.AwsSessionName
+		if len(hop.SessionName) > 0 {
+			sessionName = hop.SessionName
+		}
+		externalId := letmeContext.AwsExternalId
+		if len(hop.ExternalId) > 0 {
+			externalId = hop.ExternalId
+		} else if len(account.ExternalId) > 0 {
+			externalId = account.ExternalId
+		}
+		requiresMfa := i == 0
+		if hop.Mfa != nil {
+			requiresMfa = *hop.Mfa
+		}
+		region := AccountRegion(account)
+		if len(hop.Region) > 0 {
+			region = hop.Region
+		}
+
+		section.Key("role_arn").SetValue(hop.Arn)
+		section.Key("source_profile").SetValue(sourceProfile)
+		if len(sessionName) > 0 {
+			section.Key("role_session_name").SetValue(sessionName)
+		}
+		if duration > 0 {
+			section.Key("duration_seconds").SetValue(strconv.Itoa(int(duration)))
+		}
+		if requiresMfa && len(letmeContext.AwsMfaArn) > 0 {
+			section.Key("mfa_serial").SetValue(letmeContext.AwsMfaArn)
+		}
+		if len(externalId) > 0 {
+			section.Key("external_id").SetValue(externalId)
+		}
+		section.Key("region").SetValue(region)
+		section.Key("output").SetValue("json")
+		sourceProfile = hopProfileNames[i]
+	}
+	return hopProfileNames
+}
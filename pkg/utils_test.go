@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+func TestLetmeManagedMarker(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		input   string
+		managed bool
+		context string
+	}{
+		{"; letme managed context=test created=2026-01-01T00:00:00Z\n[acct]\n", true, "test"},
+		{"# note\n; another note\n; letme managed context=test created=2026-01-01T00:00:00Z\n[acct]\n", true, "test"},
+		{"; letme managed context=test created=2026-01-01T00:00:00Z\n# written by hand afterwards\n[acct]\n", false, ""},
+		{"# note\n[acct]\n", false, ""},
+		{"[acct]\n", false, ""},
+	}
+	for _, c := range cases {
+		file, err := ini.Load([]byte(c.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		section := file.Section("acct")
+		if managed := IsLetmeManaged(section); managed != c.managed {
+			t.Errorf("%q: managed %v, expected %v", c.input, managed, c.managed)
+		}
+		context, sectionCreated := LetmeManagedMetadata(section)
+		if context != c.context || (len(c.context) > 0 && !sectionCreated.Equal(created)) {
+			t.Errorf("%q: metadata %v %v, expected %v %v", c.input, context, sectionCreated, c.context, created)
+		}
+	}
+}
+
+// Marking a section again keeps the comments above the marker and the time letme first wrote it
+func TestMarkLetmeManaged(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	UseContext("other")
+	defer UseContext("")
+	cases := map[string]string{
+		"# note\n; letme managed context=test created=2026-01-01T00:00:00Z\n[acct]\n": "# note\nletme managed context=other created=2026-01-01T00:00:00Z",
+		"# note\n[acct]\n": "# note\nletme managed context=other created=",
+		"[acct]\n":         "letme managed context=other created=",
+	}
+	for input, expected := range cases {
+		file, err := ini.Load([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		section := file.Section("acct")
+		MarkLetmeManaged(section)
+		if !strings.HasPrefix(section.Comment, expected) {
+			t.Errorf("%q: comment %q, expected it to start with %q", input, section.Comment, expected)
+		}
+		if !IsLetmeManaged(section) {
+			t.Errorf("%q: section is not managed after marking it", input)
+		}
+	}
+}
+
+// Accounts without regions on the catalog use the default region instead of panicking
+func TestAccountRegion(t *testing.T) {
+	if region := AccountRegion(&DynamoDbAccountConfig{Name: "noregion"}); region != DefaultAccountRegion {
+		t.Errorf("region of an account without regions is %q", region)
+	}
+	if region := AccountRegion(&DynamoDbAccountConfig{Region: []string{"eu-west-1", "us-east-2"}}); region != "eu-west-1" {
+		t.Errorf("main region is %q, expected eu-west-1", region)
+	}
+	ListJsonOutput([]DynamoDbAccountConfig{{Name: "noregion"}})
+	ListTextOutput([]DynamoDbAccountConfig{{Name: "noregion"}})
+}